@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// how often the changeLogs collection is checked for a new load
+const loadCheckEvery = 30 * time.Second
+
+// most entries a LoadCache keeps per load, past that results are computed without being stored
+const loadCacheMaxEntries = 1000
+
+var (
+	loadMu      sync.Mutex
+	lastLoad    time.Time // derived_at of the newest run in changeLogs
+	loadChecked time.Time
+)
+
+// derived_at of the newest scraper run, set once the derived collections were rebuilt after its load (the
+// change log itself is stored before that). the lookup is throttled so every cache shares one query every
+// loadCheckEvery
+func LastLoad(ctx context.Context) time.Time {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	if time.Since(loadChecked) < loadCheckEvery {
+		return lastLoad
+	}
+
+	var run struct {
+		DerivedAt time.Time `bson:"derived_at"`
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "derived_at", Value: -1}}).SetProjection(bson.M{"derived_at": 1})
+	if err := MongoDB.Collection("changeLogs").FindOne(ctx, bson.M{"derived_at": bson.M{"$exists": true}}, opts).Decode(&run); err == nil {
+		lastLoad = run.DerivedAt
+	}
+	loadChecked = time.Now()
+
+	return lastLoad
+}
+
+// results that only change when the scraper loads new data (leaderboards, trends...). every entry is dropped
+// once a newer run has finished rebuilding its derived data and is recomputed on its next request. keys come
+// from query params, so the number of entries is capped at loadCacheMaxEntries
+type LoadCache[T any] struct {
+	mu      sync.Mutex
+	load    time.Time
+	entries map[string]T
+}
+
+func NewLoadCache[T any]() *LoadCache[T] {
+	return &LoadCache[T]{entries: make(map[string]T)}
+}
+
+// the cached value for key, computed when missing or stale. errors are not cached
+func (c *LoadCache[T]) Get(ctx context.Context, key string, compute func(context.Context) (T, error)) (T, error) {
+	load := LastLoad(ctx)
+
+	c.mu.Lock()
+	if !load.Equal(c.load) {
+		c.entries = make(map[string]T)
+		c.load = load
+	}
+	v, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok {
+		return v, nil
+	}
+
+	v, err := compute(ctx)
+	if err != nil {
+		return v, err
+	}
+
+	c.mu.Lock()
+	if load.Equal(c.load) && len(c.entries) < loadCacheMaxEntries {
+		c.entries[key] = v
+	}
+	c.mu.Unlock()
+
+	return v, nil
+}
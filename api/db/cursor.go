@@ -0,0 +1,54 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ErrCursorSort = errors.New("cursor does not match the requested sort")
+
+// what an opaque cursor carries: the sort it was made for and the sort key (ending in _id) of the item it points at
+type cursor struct {
+	Sort   string `bson:"s"`
+	Values bson.A `bson:"v"`
+}
+
+// "date:-1,_id:1", used to reject a cursor that is replayed against a different sort
+func sortSignature(sort bson.D) string {
+	parts := make([]string, 0, len(sort))
+	for _, e := range sort {
+		parts = append(parts, fmt.Sprintf("%s:%v", e.Key, e.Value))
+	}
+	return strings.Join(parts, ",")
+}
+
+// encode the position of a document in the sort as an opaque url safe token. bson keeps the value types
+// (dates, numbers) intact so the keyset filter built from the token compares like for like
+func EncodeCursor(sort bson.D, values []any) string {
+	raw, err := bson.Marshal(cursor{Sort: sortSignature(sort), Values: bson.A(values)})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(token string, sort bson.D) ([]any, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var c cursor
+	if err := bson.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if c.Sort != sortSignature(sort) || len(c.Values) != len(sort) {
+		return nil, ErrCursorSort
+	}
+
+	return []any(c.Values), nil
+}
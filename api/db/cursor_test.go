@@ -0,0 +1,142 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	date := time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		sort   bson.D
+		values []any
+		want   []any // as decoded, bson keeps the types
+	}{
+		{
+			name:   "id",
+			sort:   bson.D{{Key: "_id", Value: 1}},
+			values: []any{"a1b2"},
+			want:   []any{"a1b2"},
+		},
+		{
+			name:   "date then id",
+			sort:   bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: 1}},
+			values: []any{date, "e1"},
+			want:   []any{bson.NewDateTimeFromTime(date), "e1"},
+		},
+		{
+			name:   "numbers",
+			sort:   bson.D{{Key: "career_stats.slpm", Value: -1}, {Key: "fights", Value: 1}, {Key: "_id", Value: 1}},
+			values: []any{4.25, int64(12), "f1"},
+			want:   []any{4.25, int64(12), "f1"},
+		},
+		{
+			name:   "missing value",
+			sort:   bson.D{{Key: "nickname", Value: 1}, {Key: "_id", Value: 1}},
+			values: []any{nil, "f2"},
+			want:   []any{nil, "f2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := EncodeCursor(tt.sort, tt.values)
+			if token == "" {
+				t.Fatal("empty cursor")
+			}
+
+			got, err := DecodeCursor(token, tt.sort)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	byDate := bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: 1}}
+	token := EncodeCursor(byDate, []any{time.Now(), "e1"})
+
+	tests := []struct {
+		name    string
+		token   string
+		sort    bson.D
+		sortErr bool // ErrCursorSort rather than an invalid cursor
+	}{
+		{"other field", token, bson.D{{Key: "name", Value: -1}, {Key: "_id", Value: 1}}, true},
+		{"other direction", token, bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}, true},
+		{"fewer fields", token, bson.D{{Key: "_id", Value: 1}}, true},
+		{"not base64", "not a cursor!", byDate, false},
+		{"not bson", "aGVsbG8", byDate, false},
+		{"legacy id", "5f1c2d3e4a5b6c7d", byDate, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.token, tt.sort)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if errors.Is(err, ErrCursorSort) != tt.sortErr {
+				t.Errorf("err = %v, want ErrCursorSort %v", err, tt.sortErr)
+			}
+		})
+	}
+}
+
+func TestKeysetFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   bson.D
+		values []any
+		want   bson.M
+	}{
+		{
+			name:   "id",
+			sort:   bson.D{{Key: "_id", Value: 1}},
+			values: []any{"f1"},
+			want:   bson.M{"_id": bson.M{"$gt": "f1"}},
+		},
+		{
+			name:   "descending then id",
+			sort:   bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: 1}},
+			values: []any{"2020", "e1"},
+			want: bson.M{"$or": bson.A{
+				bson.M{"$or": bson.A{bson.M{"date": bson.M{"$lt": "2020"}}, bson.M{"date": nil}}},
+				bson.M{"$and": bson.A{bson.M{"date": "2020"}, bson.M{"_id": bson.M{"$gt": "e1"}}}},
+			}},
+		},
+		{
+			name:   "null ascending",
+			sort:   bson.D{{Key: "nickname", Value: 1}, {Key: "_id", Value: 1}},
+			values: []any{nil, "f1"},
+			want: bson.M{"$or": bson.A{
+				bson.M{"nickname": bson.M{"$ne": nil}},
+				bson.M{"$and": bson.A{bson.M{"nickname": nil}, bson.M{"_id": bson.M{"$gt": "f1"}}}},
+			}},
+		},
+		{
+			name:   "null descending",
+			sort:   bson.D{{Key: "nickname", Value: -1}, {Key: "_id", Value: 1}},
+			values: []any{nil, "f1"},
+			want:   bson.M{"$and": bson.A{bson.M{"nickname": nil}, bson.M{"_id": bson.M{"$gt": "f1"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keysetFilter(tt.sort, tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/go-chi/render"
+)
+
+// the envelope is opt in while the original response bodies (i.e. {"fights": [...]}) are deprecated: clients
+// ask for it with 'Accept: application/vnd.ufcapi.v2+json' or '?version=2'. the original bodies are what
+// everyone else gets (including 'application/vnd.ufcapi.v1+json' and '?version=1'), marked with a
+// Deprecation header
+const (
+	LegacyMediaType   = "application/vnd.ufcapi.v1+json"
+	EnvelopeMediaType = "application/vnd.ufcapi.v2+json"
+)
+
+func WantsLegacy(r *http.Request) bool {
+	if v := r.URL.Query().Get("version"); v != "" {
+		return v != "2"
+	}
+	return !strings.Contains(r.Header.Get("Accept"), EnvelopeMediaType)
+}
+
+// render a list page as the envelope {items, next_cursor, prev_cursor, total} for v2 clients, or as 'legacy'.
+// both get the cursors as X-Next-After / X-Prev-Before and as RFC 8288 Link headers
+func RenderPage[T any](w http.ResponseWriter, r *http.Request, page *Page[T], legacy any) {
+	var links []string
+
+	if page.NextCursor != nil {
+		w.Header().Set("X-Next-After", *page.NextCursor)
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, "after", *page.NextCursor)))
+	}
+	if page.PrevCursor != nil {
+		w.Header().Set("X-Prev-Before", *page.PrevCursor)
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, "before", *page.PrevCursor)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	// the body depends on Accept so shared caches must not mix the two shapes
+	w.Header().Add("Vary", "Accept")
+	if WantsLegacy(r) {
+		w.Header().Set("Deprecation", "true")
+	}
+
+	if page.fields == nil && page.embeds == nil {
+		if WantsLegacy(r) {
+			RenderJSON(w, r, legacy)
+			return
+		}
+		RenderJSON(w, r, page)
+		return
+	}
+
+	// ?fields= and ?expand= apply to the items of either shape
+	pruned, err := Prune(page.Items, page.fields)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "encode error")
+		return
+	}
+
+	items := pruned.([]any)
+	for i := range items {
+		if i < len(page.embeds) {
+			items[i] = embed(items[i], page.embeds[i])
+		}
+	}
+
+	if WantsLegacy(r) {
+		body, err := Prune(legacy, nil)
+		if err != nil {
+			render.Status(r, 500)
+			render.PlainText(w, r, "encode error")
+			return
+		}
+		// the v1 body is a single {"<resource>": [...]} key
+		for k := range body.(map[string]any) {
+			body.(map[string]any)[k] = items
+		}
+		RenderJSON(w, r, body)
+		return
+	}
+
+	RenderJSON(w, r, Page[any]{
+		Items:      items,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Total:      page.Total,
+	})
+}
+
+// render a single document, reduced to ?fields= when it is set, with the embedded documents (?expand=) added
+func RenderFields(w http.ResponseWriter, r *http.Request, v any, embeds map[string]any) {
+	fields, err := FieldsFromQuery(r, FieldsOf(v))
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+	if fields == nil && embeds == nil {
+		RenderJSON(w, r, v)
+		return
+	}
+
+	doc, err := Prune(v, fields)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "encode error")
+		return
+	}
+	RenderJSON(w, r, embed(doc, embeds))
+}
+
+// the current request url with the cursor swapped for the given one
+func pageURL(r *http.Request, param, cursor string) string {
+	q := r.URL.Query()
+	q.Del("after")
+	q.Del("before")
+	q.Set(param, cursor)
+
+	u := *r.URL
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// the fields of a resource that can be selected with ?fields=, json dot path -> document path
+type Fields map[string]string
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// every field of the struct v (and of the structs it embeds, slices included) keyed by its json dot path
+func FieldsOf(v any) Fields {
+	out := Fields{}
+	collectFields(reflect.TypeOf(v), "", "", out)
+	return out
+}
+
+func collectFields(t reflect.Type, jsonPrefix, bsonPrefix string, out Fields) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		jsonName := tagName(f.Tag.Get("json"))
+		bsonName := tagName(f.Tag.Get("bson"))
+		if jsonName == "" || jsonName == "-" || bsonName == "" || bsonName == "-" {
+			continue
+		}
+
+		jp, bp := jsonName, bsonName
+		if jsonPrefix != "" {
+			jp, bp = jsonPrefix+"."+jsonName, bsonPrefix+"."+bsonName
+		}
+
+		out[jp] = bp
+		collectFields(f.Type, jp, bp, out)
+	}
+}
+
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+// parse ?fields=id,method,participants.fighter_name against the allowlist. returns the selected json paths
+// (nil when the parameter is absent) with paths already covered by a selected parent removed
+func FieldsFromQuery(r *http.Request, allowed Fields) ([]string, error) {
+	v := r.URL.Query().Get("fields")
+	if v == "" {
+		return nil, nil
+	}
+
+	var paths []string
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if _, ok := allowed[f]; !ok {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		paths = append(paths, f)
+	}
+
+	return dropCovered(paths), nil
+}
+
+// remove duplicates and paths whose parent is also in the list (mongo rejects overlapping projections)
+func dropCovered(paths []string) []string {
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	out := paths[:0]
+	for _, p := range paths {
+		covered := false
+		for _, q := range out {
+			if strings.HasPrefix(p, q+".") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+type fieldsCtxKey struct{}
+
+// document paths List always fetches for this request, even when ?fields= leaves them out (i.e. the keys
+// ?expand= joins on). they are only used server side and are not rendered
+func WithFields(r *http.Request, paths ...string) *http.Request {
+	extra, _ := r.Context().Value(fieldsCtxKey{}).([]string)
+	extra = append(slices.Clone(extra), paths...)
+	return r.WithContext(context.WithValue(r.Context(), fieldsCtxKey{}, extra))
+}
+
+func requiredFields(r *http.Request) []string {
+	extra, _ := r.Context().Value(fieldsCtxKey{}).([]string)
+	return extra
+}
+
+// mongo projection for the selected fields plus the extra document paths (sort keys) the query needs
+func projection(paths []string, allowed Fields, extra ...string) bson.M {
+	docPaths := make([]string, 0, len(paths)+len(extra))
+	for _, p := range paths {
+		docPaths = append(docPaths, allowed[p])
+	}
+	docPaths = dropCovered(append(docPaths, extra...))
+
+	proj := bson.M{}
+	for _, p := range docPaths {
+		proj[p] = 1
+	}
+	return proj
+}
+
+// mongo projection for ?fields= on a document of type T: the selected fields, the paths WithFields asked for
+// and the extra document paths. nil when ?fields= is absent
+func FieldsProjection[T any](r *http.Request, extra ...string) (bson.M, error) {
+	allowed := FieldsOf(*new(T))
+	fields, err := FieldsFromQuery(r, allowed)
+	if err != nil || fields == nil {
+		return nil, err
+	}
+	return projection(fields, allowed, append(requiredFields(r), extra...)...), nil
+}
+
+// v reduced to the selected json paths (nil keeps everything). arrays keep every element, each reduced the same way
+func Prune(v any, paths []string) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	if paths == nil {
+		return doc, nil
+	}
+	return pruneValue(doc, pathTree(paths)), nil
+}
+
+// add the embedded documents to a pruned document
+func embed(doc any, embeds map[string]any) any {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return doc
+	}
+	for k, v := range embeds {
+		m[k] = v
+	}
+	return m
+}
+
+// a.b, a.c, d -> {a: {b: nil, c: nil}, d: nil}. a nil subtree keeps the whole value
+type fieldTree map[string]fieldTree
+
+func pathTree(paths []string) fieldTree {
+	root := fieldTree{}
+	for _, p := range paths {
+		node := root
+		parts := strings.Split(p, ".")
+		for i, part := range parts {
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			child, ok := node[part]
+			if ok && child == nil {
+				break // a parent is already selected whole
+			}
+			if !ok {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return root
+}
+
+func pruneValue(v any, tree fieldTree) any {
+	if tree == nil {
+		return v
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(tree))
+		for k, sub := range tree {
+			if val, ok := t[k]; ok {
+				out[k] = pruneValue(val, sub)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, el := range t {
+			out[i] = pruneValue(el, tree)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 
+	"github.com/anthonybliss1/ufc-api/scrape/data"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
@@ -12,7 +13,14 @@ func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 	_, _ = db.Collection("fights").Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "_id", Value: 1}}},
 		{Keys: bson.D{{Key: "participants.fighter_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "participants.fighter_id", Value: 1}, {Key: "date", Value: -1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "date", Value: -1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "bout", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: 1}}},
 		{Keys: bson.D{{Key: "method", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "division", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "method_category", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "technique", Value: 1}, {Key: "_id", Value: 1}}},
 		// optional text index for q
 		// {Keys: bson.D{{Key: "fight_detail", Value: "text"}, {Key: "method", Value: "text"}, {Key: "method_detail", Value: "text"}, {Key: "referee", Value: "text"}}},
 	})
@@ -24,6 +32,32 @@ func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 		{Keys: bson.D{{Key: "career_stats.slpm", Value: 1}}},
 	})
 
+	// Fighter versions (history and ?as_of= lookups)
+	_, _ = db.Collection("fighterVersions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "fighter_id", Value: 1}, {Key: "valid_from", Value: -1}}},
+	})
+
+	// Ratings (snapshot per fighter per fight, written by the scraper)
+	_, _ = db.Collection("ratings").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "fighter_id", Value: 1}, {Key: "date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "date", Value: -1}, {Key: "fight_id", Value: -1}}},
+		{Keys: bson.D{{Key: "fight_id", Value: 1}}},
+	})
+
+	// Fighter divisions (derived by the scraper after every load)
+	_, _ = db.Collection("fighterDivisions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "current", Value: 1}}},
+		{Keys: bson.D{{Key: "primary", Value: 1}}},
+	})
+
+	// Change logs (the api caches follow the newest derived_at)
+	_, _ = db.Collection("changeLogs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "derived_at", Value: -1}}},
+	})
+	_, _ = db.Collection("changeLogEntries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "run_id", Value: 1}, {Key: "collection", Value: 1}, {Key: "part", Value: 1}}},
+	})
+
 	// Events
 	_, _ = db.Collection("events").Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{Keys: bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: 1}}},
@@ -31,17 +65,9 @@ func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 		{Keys: bson.D{{Key: "location", Value: 1}}},
 	})
 
-	// Upcoming
-	_, _ = db.Collection("upcomingEvents").Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{Keys: bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}},
-		{Keys: bson.D{{Key: "name", Value: 1}}},
-		{Keys: bson.D{{Key: "location", Value: 1}}},
-	})
-	_, _ = db.Collection("upcomingFights").Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{Keys: bson.D{{Key: "upcoming_event_id", Value: 1}, {Key: "_id", Value: 1}}},
-		{Keys: bson.D{{Key: "tale_of_the_tape._id", Value: 1}, {Key: "_id", Value: 1}}},  // if embedded fighters have _id
-		{Keys: bson.D{{Key: "tale_of_the_tape.name", Value: 1}, {Key: "_id", Value: 1}}}, // helps name filters a bit
-	})
+	// Upcoming (shared with the scraper's staging collections)
+	_, _ = db.Collection("upcomingEvents").Indexes().CreateMany(ctx, data.UpcomingEventIndexes)
+	_, _ = db.Collection("upcomingFights").Indexes().CreateMany(ctx, data.UpcomingFightIndexes)
 
 	return nil
 }
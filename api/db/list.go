@@ -0,0 +1,366 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// the fields a resource can be sorted on, ?sort= name -> document path
+type SortFields map[string]string
+
+// parse ?sort=-career_stats.slpm,name into a mongo sort ('-' for descending). every field must be in the
+// allowlist and _id is always added last as a tiebreaker so the sort is a total order, which cursor pagination relies on
+func SortFromQuery(r *http.Request, allowed SortFields, def bson.D) (bson.D, error) {
+	v := r.URL.Query().Get("sort")
+	if v == "" {
+		return withTiebreaker(def), nil
+	}
+
+	sort := bson.D{}
+	seen := make(map[string]bool)
+
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		dir := 1
+		if strings.HasPrefix(f, "-") {
+			dir = -1
+			f = f[1:]
+		}
+		f = strings.TrimPrefix(f, "+")
+
+		path, ok := allowed[f]
+		if !ok {
+			return nil, fmt.Errorf("cannot sort by %q", f)
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		sort = append(sort, bson.E{Key: path, Value: dir})
+	}
+
+	return withTiebreaker(sort), nil
+}
+
+func withTiebreaker(sort bson.D) bson.D {
+	for _, e := range sort {
+		if e.Key == "_id" {
+			return sort
+		}
+	}
+	return append(sort, bson.E{Key: "_id", Value: 1})
+}
+
+// documents that come after the one whose sort key is 'values' (one value per sort field) in sort order:
+// (k1 > v1) or (k1 = v1 and k2 > v2) or ... with '>' flipped for descending fields. mongo sorts missing/null
+// values first, so they are handled explicitly instead of with $gt/$lt which never match across types
+func keysetFilter(sort bson.D, values []any) bson.M {
+	or := bson.A{}
+
+	for i, e := range sort {
+		and := bson.A{}
+		for j := 0; j < i; j++ {
+			and = append(and, bson.M{sort[j].Key: values[j]})
+		}
+
+		next, ok := pastValue(e.Key, values[i], e.Value == -1)
+		if !ok {
+			continue
+		}
+		and = append(and, next)
+
+		if len(and) == 1 {
+			or = append(or, and[0])
+		} else {
+			or = append(or, bson.M{"$and": and})
+		}
+	}
+
+	if len(or) == 1 {
+		return or[0].(bson.M)
+	}
+	return bson.M{"$or": or}
+}
+
+// condition for a single field being strictly past v in its sort direction, false when nothing can be
+func pastValue(key string, v any, desc bool) (bson.M, bool) {
+	switch {
+	case v == nil && desc:
+		return nil, false // null sorts last descending
+	case v == nil:
+		return bson.M{key: bson.M{"$ne": nil}}, true
+	case desc:
+		return bson.M{"$or": bson.A{bson.M{key: bson.M{"$lt": v}}, bson.M{key: nil}}}, true
+	default:
+		return bson.M{key: bson.M{"$gt": v}}, true
+	}
+}
+
+// the sort key of a stored document
+func sortValues(doc bson.Raw, sort bson.D) []any {
+	values := make([]any, len(sort))
+	for i, e := range sort {
+		rv, err := doc.LookupErr(strings.Split(e.Key, ".")...)
+		if err != nil {
+			continue
+		}
+		var v any
+		if err := rv.Unmarshal(&v); err == nil {
+			values[i] = v
+		}
+	}
+	return values
+}
+
+// ?after= and ?before= as a keyset filter. backward is true for ?before=, which is queried in the reversed sort.
+// a plain _id (what X-Next-After used to return) is still accepted as ?after= and looked up to find its position
+func cursorFilter(r *http.Request, coll *mongo.Collection, sort bson.D) (filter bson.M, backward bool, err error) {
+	after, before := AfterFromQuery(r), r.URL.Query().Get("before")
+	if after != "" && before != "" {
+		return nil, false, fmt.Errorf("after and before cannot be combined")
+	}
+
+	if before != "" {
+		values, err := DecodeCursor(before, sort)
+		if err != nil {
+			return nil, false, err
+		}
+		return keysetFilter(reverseSort(sort), values), true, nil
+	}
+
+	if after == "" {
+		return nil, false, nil
+	}
+
+	values, err := DecodeCursor(after, sort)
+	if errors.Is(err, ErrCursorSort) {
+		return nil, false, err
+	}
+	if err != nil {
+		if values, err = legacyAfter(r.Context(), coll, sort, after); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return keysetFilter(sort, values), false, nil
+}
+
+// sort key of the document with _id 'after'
+func legacyAfter(ctx context.Context, coll *mongo.Collection, sort bson.D, after string) ([]any, error) {
+	projection := bson.M{}
+	for _, e := range sort {
+		projection[e.Key] = 1
+	}
+
+	doc, err := coll.FindOne(ctx, bson.M{"_id": after}, options.FindOne().SetProjection(projection)).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return sortValues(doc, sort), nil
+}
+
+func reverseSort(sort bson.D) bson.D {
+	rev := make(bson.D, len(sort))
+	for i, e := range sort {
+		dir := 1
+		if e.Value == 1 {
+			dir = -1
+		}
+		rev[i] = bson.E{Key: e.Key, Value: dir}
+	}
+	return rev
+}
+
+// one page of a list endpoint. this is the response envelope, see RenderPage
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`     // ?after= value for the next page, null on the last page
+	PrevCursor *string `json:"prev_cursor"`     // ?before= value for the previous page, null on the first page
+	Total      *int64  `json:"total,omitempty"` // documents matching the filter, only with ?count=true
+
+	fields []string         // ?fields= selection, applied when the page is rendered
+	embeds []map[string]any // ?expand= documents for each item, see Embed
+}
+
+// attach related documents to the items, embeds[i] is merged into Items[i] when the page is rendered
+func (p *Page[T]) Embed(embeds []map[string]any) {
+	p.embeds = embeds
+}
+
+// run a paginated find honouring ?sort=, ?limit=, ?after=, ?before=, ?count= and ?fields= (checked against the
+// json fields of T). the page carries opaque cursors for the neighbouring pages when they exist. on failure the
+// error response is written and ok is false
+func List[T any](w http.ResponseWriter, r *http.Request, collName string, filter bson.M, allowed SortFields, def bson.D) (page *Page[T], ok bool) {
+	coll := MongoDB.Collection(collName)
+
+	sort, err := SortFromQuery(r, allowed, def)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return nil, false
+	}
+
+	selectable := FieldsOf(*new(T))
+	fields, err := FieldsFromQuery(r, selectable)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return nil, false
+	}
+
+	position, backward, err := cursorFilter(r, coll, sort)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return nil, false
+	}
+
+	findSort := sort
+	if backward {
+		findSort = reverseSort(sort)
+	}
+
+	// one extra document tells whether there is another page in the direction we are reading
+	limit := LimitFromQuery(r, 50, 50)
+	opts := options.Find().SetLimit(limit + 1).SetSort(findSort)
+	if fields != nil {
+		// the sort keys are always fetched, the cursors are built from them
+		keys := requiredFields(r)
+		for _, e := range sort {
+			keys = append(keys, e.Key)
+		}
+		opts.SetProjection(projection(fields, selectable, keys...))
+	}
+
+	cur, err := coll.Find(r.Context(), and(filter, position), opts)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return nil, false
+	}
+	defer cur.Close(r.Context())
+
+	page = &Page[T]{Items: []T{}, fields: fields}
+
+	var docs []bson.Raw
+	for cur.Next(r.Context()) {
+		var item T
+		if err := cur.Decode(&item); err != nil {
+			render.Status(r, 500)
+			render.PlainText(w, r, "decode error")
+			return nil, false
+		}
+		page.Items = append(page.Items, item)
+		docs = append(docs, append(bson.Raw(nil), cur.Current...))
+	}
+
+	more := int64(len(page.Items)) > limit
+	if more {
+		page.Items, docs = page.Items[:limit], docs[:limit]
+	}
+	if backward {
+		slices.Reverse(page.Items)
+		slices.Reverse(docs)
+	}
+
+	if n := len(docs); n > 0 {
+		// reading forward there is a next page only if we saw more, reading backward we came from it
+		if more || backward {
+			next := EncodeCursor(sort, sortValues(docs[n-1], sort))
+			page.NextCursor = &next
+		}
+		if (more && backward) || (!backward && position != nil) {
+			prev := EncodeCursor(sort, sortValues(docs[0], sort))
+			page.PrevCursor = &prev
+		}
+	}
+
+	// counting can scan the whole collection so it is opt in
+	if r.URL.Query().Get("count") == "true" {
+		total, err := coll.CountDocuments(r.Context(), filter)
+		if err != nil {
+			render.Status(r, 500)
+			render.PlainText(w, r, "db error")
+			return nil, false
+		}
+		page.Total = &total
+	}
+
+	return page, true
+}
+
+// combine two filters, either may be empty
+func and(a, b bson.M) bson.M {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	return bson.M{"$and": bson.A{a, b}}
+}
+
+// one page of a list that is already sorted in memory (results of a LoadCache), with the same ?after=, ?before=,
+// ?limit= and ?count= as List. key is the sort key of an item as it goes in a cursor (one value per field of
+// sort, ints as int64) and compare orders an item against a decoded key like cmp.Compare. on failure the error
+// response is written and ok is false
+func SlicePage[T any](w http.ResponseWriter, r *http.Request, items []T, sort bson.D, key func(T) []any, compare func(T, []any) int, defLimit, maxLimit int64) (page *Page[T], ok bool) {
+	after, before := AfterFromQuery(r), r.URL.Query().Get("before")
+	if after != "" && before != "" {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("after and before cannot be combined")))
+		return nil, false
+	}
+
+	limit := int(LimitFromQuery(r, defLimit, maxLimit))
+	start, end := 0, min(limit, len(items))
+
+	if token := after + before; token != "" {
+		values, err := DecodeCursor(token, sort)
+		if err != nil {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+			return nil, false
+		}
+
+		if after != "" {
+			start = len(items)
+			if i := slices.IndexFunc(items, func(it T) bool { return compare(it, values) > 0 }); i >= 0 {
+				start = i
+			}
+			end = min(start+limit, len(items))
+		} else {
+			end = len(items)
+			if i := slices.IndexFunc(items, func(it T) bool { return compare(it, values) >= 0 }); i >= 0 {
+				end = i
+			}
+			start = max(end-limit, 0)
+		}
+	}
+
+	page = &Page[T]{Items: items[start:end]}
+	if start < end {
+		if end < len(items) {
+			next := EncodeCursor(sort, key(items[end-1]))
+			page.NextCursor = &next
+		}
+		if start > 0 {
+			prev := EncodeCursor(sort, key(items[start]))
+			page.PrevCursor = &prev
+		}
+	}
+
+	if r.URL.Query().Get("count") == "true" {
+		total := int64(len(items))
+		page.Total = &total
+	}
+
+	return page, true
+}
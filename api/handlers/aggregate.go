@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// limits of /stats/aggregate
+const (
+	aggregateTimeout   = 10 * time.Second
+	maxAggregateGroups = 1000
+	maxGroupBy         = 3
+	maxMetrics         = 10
+)
+
+// a group_by field: its expression and the lookup it reads from, if any
+type aggregateKey struct {
+	expr   any
+	lookup string
+}
+
+// what /stats/aggregate can do with one collection
+type aggregateResource struct {
+	collection string
+	filter     func(http.ResponseWriter, *http.Request) (bson.M, bool) // the list endpoint's filters
+	groups     map[string]aggregateKey
+	fields     map[string]any // numeric fields the metrics can use
+}
+
+// stages adding the fields a lookup provides, by lookup name
+var aggregateLookups = map[string]bson.A{
+	"event": {
+		bson.M{"$lookup": bson.M{"from": "events", "localField": "event_id", "foreignField": "_id", "as": "event"}},
+		bson.M{"$set": bson.M{"date": bson.M{"$first": "$event.date"}, "location": bson.M{"$first": "$event.location"}}},
+		bson.M{"$unset": "event"},
+	},
+	"fighterDivision": {
+		bson.M{"$lookup": bson.M{"from": "fighterDivisions", "localField": "_id", "foreignField": "_id", "as": "fighter_division"}},
+		bson.M{"$set": bson.M{"division": bson.M{"$first": "$fighter_division.current"}}},
+		bson.M{"$unset": "fighter_division"},
+	},
+}
+
+// sum of a participants field over both fighters
+func bothFighters(field string) bson.M {
+	return bson.M{"$sum": "$participants." + field}
+}
+
+// the first number in a string field ("72.0\"", "155 lbs."), null when there is none
+func numberInExpr(field string) bson.M {
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"m": bson.M{"$regexFind": bson.M{"input": bson.M{"$ifNull": bson.A{field, ""}}, "regex": `[0-9]+(\.[0-9]+)?`}}},
+		"in":   bson.M{"$convert": bson.M{"input": "$$m.match", "to": "double", "onError": nil, "onNull": nil}},
+	}}
+}
+
+// inches in a "5' 11\"" height, null when it does not parse
+func heightInchesExpr() bson.M {
+	toInt := func(i int) bson.M {
+		return bson.M{"$toInt": bson.M{"$getField": bson.M{"field": "match", "input": bson.M{"$arrayElemAt": bson.A{"$$parts", i}}}}}
+	}
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"parts": bson.M{"$regexFindAll": bson.M{"input": bson.M{"$ifNull": bson.A{"$height", ""}}, "regex": "[0-9]+"}}},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$size": "$$parts"}, 2}},
+			bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{toInt(0), 12}}, toInt(1)}},
+			nil,
+		}},
+	}}
+}
+
+var aggregateResources = map[string]aggregateResource{
+	"fights": {
+		collection: "fights",
+		filter:     fightFilterFromQuery,
+		groups: map[string]aggregateKey{
+			"year":     {expr: bson.M{"$year": "$date"}, lookup: "event"},
+			"division": {expr: "$division"},
+			"method":   {expr: "$method_category"},
+			"referee":  {expr: "$referee"},
+			"location": {expr: "$location", lookup: "event"},
+		},
+		fields: map[string]any{
+			"round":             "$round",
+			"fight_seconds":     fightSecondsExpr(),
+			"knockdowns":        bothFighters("kd"),
+			"sig_str_landed":    bothFighters("sig_str_landed"),
+			"sig_str_attempted": bothFighters("sig_str_attempted"),
+			"total_str_landed":  bothFighters("total_str_landed"),
+			"td_landed":         bothFighters("td_landed"),
+			"td_attempted":      bothFighters("td_attempted"),
+			"sub_attempts":      bothFighters("sub"),
+		},
+	},
+	"fighters": {
+		collection: "fighters",
+		filter:     fighterFilterFromQuery,
+		groups: map[string]aggregateKey{
+			"stance":   {expr: "$stance"},
+			"division": {expr: "$division", lookup: "fighterDivision"},
+		},
+		fields: map[string]any{
+			"height_in": heightInchesExpr(),
+			"reach_in":  numberInExpr("$reach_in"),
+			"weight_lb": numberInExpr("$weight_lb"),
+			"slpm":      "$career_stats.slpm",
+			"sapm":      "$career_stats.sapm",
+			"td_avg":    "$career_stats.td_avg",
+			"sub_avg":   "$career_stats.sub_avg",
+		},
+	},
+}
+
+// metric operations. the percentiles are worked out from the pushed values
+var aggregateOps = map[string]string{
+	"avg": "$avg",
+	"sum": "$sum",
+	"p50": "$push",
+	"p90": "$push",
+}
+
+var opQuantiles = map[string]float64{"p50": 0.5, "p90": 0.9}
+
+// a metric of /stats/aggregate, "count" or "<op>:<field>"
+type aggregateMetric struct {
+	Name  string
+	Op    string
+	Field string
+}
+
+// one group of an aggregation
+type AggregateGroup struct {
+	Key     map[string]any      `json:"key"` // group_by field -> value, null when the documents lack it
+	Count   int                 `json:"count"`
+	Metrics map[string]*float64 `json:"metrics,omitempty"` // null when no document had the field
+}
+
+// response of /stats/aggregate
+type Aggregation struct {
+	Resource  string           `json:"resource"`
+	GroupBy   []string         `json:"group_by"`
+	Metrics   []string         `json:"metrics"`
+	Groups    []AggregateGroup `json:"groups"`
+	Truncated bool             `json:"truncated,omitempty"` // more than maxAggregateGroups groups
+}
+
+// /stats/aggregate?resource=fights&group_by=year,method&metrics=count,avg:fight_seconds&division=lightweight
+// groups the documents matching the list endpoint's filters and computes the metrics of each group
+func GetAggregate(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	name := q.Get("resource")
+	if name == "" {
+		name = "fights"
+	}
+	res, ok := aggregateResources[name]
+	if !ok {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("resource must be fights or fighters, got %q", name)))
+		return
+	}
+
+	groupBy := splitParam(q["group_by"])
+	if len(groupBy) > maxGroupBy {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("at most %d group_by fields", maxGroupBy)))
+		return
+	}
+	for _, g := range groupBy {
+		if _, ok := res.groups[g]; !ok {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("%s cannot be grouped by %q, use one of %v", name, g, sortedKeys(res.groups))))
+			return
+		}
+	}
+
+	metrics, err := parseAggregateMetrics(res, splitParam(q["metrics"]))
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+
+	filter, ok := res.filter(w, r)
+	if !ok {
+		return
+	}
+
+	agg := Aggregation{Resource: name, GroupBy: append([]string{}, groupBy...), Metrics: []string{"count"}, Groups: []AggregateGroup{}}
+	for _, m := range metrics {
+		agg.Metrics = append(agg.Metrics, m.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), aggregateTimeout)
+	defer cancel()
+
+	cur, err := db.MongoDB.Collection(res.collection).Aggregate(ctx, aggregatePipeline(res, filter, groupBy, metrics))
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	var rows []bson.M
+	if err := cur.All(ctx, &rows); err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	if len(rows) > maxAggregateGroups {
+		rows = rows[:maxAggregateGroups]
+		agg.Truncated = true
+	}
+	for _, row := range rows {
+		agg.Groups = append(agg.Groups, aggregateGroupOf(row, groupBy, metrics))
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderJSON(w, r, agg)
+}
+
+// comma separated and repeated values of a query param
+func splitParam(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" && !slices.Contains(out, part) {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// "count" or "<op>:<field>" with an allowlisted op and field. count is always computed
+func parseAggregateMetrics(res aggregateResource, names []string) ([]aggregateMetric, error) {
+	if len(names) > maxMetrics {
+		return nil, fmt.Errorf("at most %d metrics", maxMetrics)
+	}
+
+	var metrics []aggregateMetric
+	for _, name := range names {
+		if name == "count" {
+			continue
+		}
+		op, field, ok := strings.Cut(name, ":")
+		if !ok {
+			return nil, fmt.Errorf("metric %q must be count or <op>:<field>", name)
+		}
+		if _, ok := aggregateOps[op]; !ok {
+			return nil, fmt.Errorf("unknown metric op %q, use one of %v", op, sortedKeys(aggregateOps))
+		}
+		if _, ok := res.fields[field]; !ok {
+			return nil, fmt.Errorf("unknown metric field %q, use one of %v", field, sortedKeys(res.fields))
+		}
+		metrics = append(metrics, aggregateMetric{Name: name, Op: op, Field: field})
+	}
+	return metrics, nil
+}
+
+// every expression comes from the allowlists, the request only picks among them. group keys and metric
+// values are written as k0.. and m0.. so no request value ever becomes a field name
+func aggregatePipeline(res aggregateResource, filter bson.M, groupBy []string, metrics []aggregateMetric) bson.A {
+	pipeline := bson.A{bson.M{"$match": filter}}
+
+	var lookups []string
+	for _, g := range groupBy {
+		if l := res.groups[g].lookup; l != "" && !slices.Contains(lookups, l) {
+			lookups = append(lookups, l)
+			pipeline = append(pipeline, aggregateLookups[l]...)
+		}
+	}
+
+	id := bson.M{}
+	for i, g := range groupBy {
+		id[fmt.Sprintf("k%d", i)] = res.groups[g].expr
+	}
+	group := bson.M{"_id": id, "count": bson.M{"$sum": 1}}
+	for i, m := range metrics {
+		group[fmt.Sprintf("m%d", i)] = bson.M{aggregateOps[m.Op]: res.fields[m.Field]}
+	}
+
+	sort := bson.D{}
+	for i := range groupBy {
+		sort = append(sort, bson.E{Key: fmt.Sprintf("_id.k%d", i), Value: 1})
+	}
+	if len(sort) == 0 {
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+	}
+
+	project := bson.M{"_id": 0, "count": 1}
+	for i := range groupBy {
+		project[fmt.Sprintf("k%d", i)] = fmt.Sprintf("$_id.k%d", i)
+	}
+	for i, m := range metrics {
+		key := fmt.Sprintf("m%d", i)
+		if _, ok := opQuantiles[m.Op]; ok {
+			// only numbers, $push keeps the nulls of documents without the field
+			project[key] = bson.M{"$filter": bson.M{"input": "$" + key, "cond": bson.M{"$isNumber": "$$this"}}}
+		} else {
+			project[key] = 1
+		}
+	}
+
+	return append(pipeline,
+		bson.M{"$group": group},
+		bson.M{"$sort": sort},
+		bson.M{"$limit": maxAggregateGroups + 1},
+		bson.M{"$project": project},
+	)
+}
+
+func aggregateGroupOf(row bson.M, groupBy []string, metrics []aggregateMetric) AggregateGroup {
+	g := AggregateGroup{Key: map[string]any{}}
+	for i, name := range groupBy {
+		g.Key[name] = row[fmt.Sprintf("k%d", i)]
+	}
+	if n, ok := toFloat(row["count"]); ok {
+		g.Count = int(n)
+	}
+
+	if len(metrics) > 0 {
+		g.Metrics = map[string]*float64{}
+	}
+	for i, m := range metrics {
+		v := row[fmt.Sprintf("m%d", i)]
+		if q, ok := opQuantiles[m.Op]; ok {
+			g.Metrics[m.Name] = quantile(numbersOf(v), q)
+			continue
+		}
+		if f, ok := toFloat(v); ok {
+			g.Metrics[m.Name] = &f
+		} else {
+			g.Metrics[m.Name] = nil
+		}
+	}
+	return g
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func numbersOf(v any) []float64 {
+	var arr []any
+	switch a := v.(type) {
+	case bson.A:
+		arr = a
+	case []any:
+		arr = a
+	}
+	out := make([]float64, 0, len(arr))
+	for _, x := range arr {
+		if f, ok := toFloat(x); ok {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// the q quantile of the values, interpolated between the closest ranks. nil for no values
+func quantile(values []float64, q float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	slices.Sort(values)
+
+	pos := q * float64(len(values)-1)
+	lo := int(pos)
+	v := values[lo]
+	if lo+1 < len(values) {
+		v += (pos - float64(lo)) * (values[lo+1] - values[lo])
+	}
+	return &v
+}
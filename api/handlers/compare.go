@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	minCompare = 2
+	maxCompare = 6
+)
+
+// a fighter's UFC record counted from the fights collection
+type DerivedRecord struct {
+	Fights      int      `bson:"fights" json:"fights"`
+	Wins        int      `bson:"wins" json:"wins"`
+	Losses      int      `bson:"losses" json:"losses"`
+	Draws       int      `bson:"draws" json:"draws"`
+	NoContests  int      `bson:"no_contests" json:"no_contests"`
+	KOTKO       int      `bson:"ko_tko" json:"ko_tko"`           // wins, doctor's stoppages included
+	Submissions int      `bson:"submissions" json:"submissions"` // wins
+	Decisions   int      `bson:"decisions" json:"decisions"`     // wins
+	Finishes    int      `bson:"finishes" json:"finishes"`       // wins in data.FinishCategories
+	FinishRate  *float64 `bson:"-" json:"finish_rate"`           // share of wins that were finishes, null without wins
+}
+
+// one fighter of the comparison
+type ComparedFighter struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Division string        `json:"division,omitempty"` // current division (see /divisions)
+	Record   DerivedRecord `json:"record"`
+}
+
+// one field of the comparison with a value per fighter, in the order of ?ids. numeric fields add the
+// fighter's percentile within their division and the difference to the first fighter
+type CompareRow struct {
+	Field         string     `json:"field"`
+	Values        []any      `json:"values"`
+	Percentiles   []*float64 `json:"percentiles,omitempty"`   // share of the division with a lower value, 0-100
+	Differentials []*float64 `json:"differentials,omitempty"` // value minus the first fighter's
+}
+
+// response of /fighters/compare
+type Comparison struct {
+	Fighters []ComparedFighter `json:"fighters"`
+	Rows     []CompareRow      `json:"rows"`
+}
+
+// fields that are compared as text
+var compareText = []struct {
+	field string
+	value func(data.Fighter) any
+}{
+	{"current_record", func(f data.Fighter) any { return f.CurrentRecord }},
+	{"height", func(f data.Fighter) any { return f.Height }},
+	{"weight_lb", func(f data.Fighter) any { return f.WeightLB }},
+	{"reach_in", func(f data.Fighter) any { return f.ReachIN }},
+	{"stance", func(f data.Fighter) any { return f.Stance }},
+	{"dob", func(f data.Fighter) any { return f.DOB }},
+}
+
+// fields that are compared as numbers, false when the fighter's value is unknown
+var compareNumeric = []struct {
+	field string
+	value func(comparePoolEntry) (float64, bool)
+}{
+	{"height_inches", func(e comparePoolEntry) (float64, bool) { return data.HeightInches(e.fighter.Height) }},
+	{"reach_inches", func(e comparePoolEntry) (float64, bool) { return data.ReachInches(e.fighter.ReachIN) }},
+	{"age", func(e comparePoolEntry) (float64, bool) { return data.AgeOn(e.fighter.DOB, time.Now()) }},
+	{"ufc_fights", func(e comparePoolEntry) (float64, bool) { return float64(e.record.Fights), true }},
+	{"ufc_wins", func(e comparePoolEntry) (float64, bool) { return float64(e.record.Wins), true }},
+	{"ufc_losses", func(e comparePoolEntry) (float64, bool) { return float64(e.record.Losses), true }},
+	{"finish_rate", func(e comparePoolEntry) (float64, bool) {
+		if e.record.FinishRate == nil {
+			return 0, false
+		}
+		return *e.record.FinishRate, true
+	}},
+	{"career_stats.slpm", func(e comparePoolEntry) (float64, bool) { return float64(e.fighter.CareerStats.SLpM), true }},
+	{"career_stats.str_acc", func(e comparePoolEntry) (float64, bool) { return data.Percent(e.fighter.CareerStats.StrAcc) }},
+	{"career_stats.sapm", func(e comparePoolEntry) (float64, bool) { return float64(e.fighter.CareerStats.SApM), true }},
+	{"career_stats.str_def", func(e comparePoolEntry) (float64, bool) { return data.Percent(e.fighter.CareerStats.StrDef) }},
+	{"career_stats.td_avg", func(e comparePoolEntry) (float64, bool) { return float64(e.fighter.CareerStats.TdAvg), true }},
+	{"career_stats.td_acc", func(e comparePoolEntry) (float64, bool) { return data.Percent(e.fighter.CareerStats.TdAcc) }},
+	{"career_stats.td_def", func(e comparePoolEntry) (float64, bool) { return data.Percent(e.fighter.CareerStats.TdDef) }},
+	{"career_stats.sub_avg", func(e comparePoolEntry) (float64, bool) { return float64(e.fighter.CareerStats.SubAvg), true }},
+}
+
+type comparePoolEntry struct {
+	fighter  data.Fighter
+	division string
+	record   DerivedRecord
+}
+
+// every fighter with their record and division, and the sorted values of each numeric field per division
+// for the percentiles. rebuilt once per scraper load
+type comparePool struct {
+	entries  map[string]comparePoolEntry
+	division map[string]map[string][]float64 // division -> field -> sorted values
+}
+
+var comparePoolCache = db.NewLoadCache[*comparePool]()
+
+// 2 to 6 fighters side by side: ?ids=a,b,c
+func CompareFighters(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, v := range r.URL.Query()["ids"] {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) < minCompare || len(ids) > maxCompare {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("ids must list %d to %d different fighters", minCompare, maxCompare)))
+		return
+	}
+
+	pool, err := comparePoolCache.Get(r.Context(), "", loadComparePool)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	entries := make([]comparePoolEntry, len(ids))
+	for i, id := range ids {
+		e, ok := pool.entries[id]
+		if !ok {
+			render.Status(r, 404)
+			render.PlainText(w, r, "fighter not found: "+id)
+			return
+		}
+		entries[i] = e
+	}
+
+	c := Comparison{Fighters: make([]ComparedFighter, len(entries))}
+	for i, e := range entries {
+		c.Fighters[i] = ComparedFighter{ID: e.fighter.ID, Name: e.fighter.Name, Division: e.division, Record: e.record}
+	}
+
+	for _, t := range compareText {
+		row := CompareRow{Field: t.field, Values: make([]any, len(entries))}
+		for i, e := range entries {
+			row.Values[i] = t.value(e.fighter)
+		}
+		c.Rows = append(c.Rows, row)
+	}
+
+	for _, n := range compareNumeric {
+		row := CompareRow{
+			Field:         n.field,
+			Values:        make([]any, len(entries)),
+			Percentiles:   make([]*float64, len(entries)),
+			Differentials: make([]*float64, len(entries)),
+		}
+
+		first, firstOK := n.value(entries[0])
+		for i, e := range entries {
+			v, ok := n.value(e)
+			if !ok {
+				continue
+			}
+			row.Values[i] = v
+			if firstOK {
+				d := v - first
+				row.Differentials[i] = &d
+			}
+			if values := pool.division[e.division][n.field]; e.division != "" && len(values) > 0 {
+				p := percentile(values, v)
+				row.Percentiles[i] = &p
+			}
+		}
+		c.Rows = append(c.Rows, row)
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderJSON(w, r, c)
+}
+
+// share of the sorted values below v (ties count half), 0-100
+func percentile(sorted []float64, v float64) float64 {
+	below, _ := slices.BinarySearch(sorted, v)
+	above := below
+	for above < len(sorted) && sorted[above] == v {
+		above++
+	}
+	return (float64(below) + float64(above-below)/2) / float64(len(sorted)) * 100
+}
+
+func loadComparePool(ctx context.Context) (*comparePool, error) {
+	won := bson.M{"$eq": bson.A{"$participants.outcome", "W"}}
+	count := func(cond any) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, 1, 0}}}
+	}
+	wonBy := func(categories []string) bson.M {
+		return count(bson.M{"$and": bson.A{won, bson.M{"$in": bson.A{"$method_category", categories}}}})
+	}
+
+	pipeline := bson.A{
+		bson.M{"$unwind": "$participants"},
+		bson.M{"$group": bson.M{
+			"_id":         "$participants.fighter_id",
+			"fights":      bson.M{"$sum": 1},
+			"wins":        count(won),
+			"losses":      count(bson.M{"$eq": bson.A{"$participants.outcome", "L"}}),
+			"draws":       count(bson.M{"$eq": bson.A{"$participants.outcome", "D"}}),
+			"no_contests": count(bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$participants.outcome", bson.A{"W", "L", "D"}}}}}),
+			"ko_tko":      wonBy([]string{data.MethodKOTKO, data.MethodDoctorStoppage}),
+			"submissions": wonBy([]string{data.MethodSubmission}),
+			"decisions":   wonBy(data.DecisionCategories),
+			"finishes":    wonBy(data.FinishCategories),
+		}},
+	}
+
+	cur, err := db.MongoDB.Collection("fights").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		FighterID     string `bson:"_id"`
+		DerivedRecord `bson:",inline"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	cur, err = db.MongoDB.Collection("fighters").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var fighters []data.Fighter
+	if err := cur.All(ctx, &fighters); err != nil {
+		return nil, err
+	}
+
+	cur, err = db.MongoDB.Collection("fighterDivisions").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var divisions []data.FighterDivision
+	if err := cur.All(ctx, &divisions); err != nil {
+		return nil, err
+	}
+
+	pool := &comparePool{entries: make(map[string]comparePoolEntry, len(fighters)), division: map[string]map[string][]float64{}}
+	for _, f := range fighters {
+		pool.entries[f.ID] = comparePoolEntry{fighter: f}
+	}
+	for _, row := range rows {
+		e, ok := pool.entries[row.FighterID]
+		if !ok {
+			continue
+		}
+		e.record = row.DerivedRecord
+		if e.record.Wins > 0 {
+			rate := float64(e.record.Finishes) / float64(e.record.Wins)
+			e.record.FinishRate = &rate
+		}
+		pool.entries[row.FighterID] = e
+	}
+	for _, fd := range divisions {
+		if e, ok := pool.entries[fd.ID]; ok {
+			e.division = fd.Current
+			pool.entries[fd.ID] = e
+		}
+	}
+
+	for _, e := range pool.entries {
+		if e.division == "" {
+			continue
+		}
+		if pool.division[e.division] == nil {
+			pool.division[e.division] = map[string][]float64{}
+		}
+		for _, n := range compareNumeric {
+			if v, ok := n.value(e); ok {
+				pool.division[e.division][n.field] = append(pool.division[e.division][n.field], v)
+			}
+		}
+	}
+	for _, fields := range pool.division {
+		for _, values := range fields {
+			slices.Sort(values)
+		}
+	}
+
+	return pool, nil
+}
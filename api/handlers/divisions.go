@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// a division with its activity
+type DivisionSummary struct {
+	data.Division
+	Fights         int        `json:"fights"`
+	TitleFights    int        `json:"title_fights"`
+	ActiveFighters int        `json:"active_fighters"` // fighters whose current division it is
+	LastFight      *time.Time `json:"last_fight,omitempty"`
+}
+
+// the division list only changes with a scraper load
+var divisionsCache = db.NewLoadCache[[]DivisionSummary]()
+
+// every division of the taxonomy, lightest first
+func ListDivisions(w http.ResponseWriter, r *http.Request) {
+	divisions, err := divisionsCache.Get(r.Context(), "", computeDivisions)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderPage(w, r, &db.Page[DivisionSummary]{Items: divisions}, map[string]any{"divisions": divisions})
+}
+
+func computeDivisions(ctx context.Context) ([]DivisionSummary, error) {
+	pipeline := append(withEventDate(),
+		bson.M{"$group": bson.M{
+			"_id":    "$division",
+			"fights": bson.M{"$sum": 1},
+			"title_fights": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$regexMatch": bson.M{"input": "$fight_detail", "regex": titleFightRegex, "options": "i"}}, 1, 0,
+			}}},
+			"last_fight": bson.M{"$max": "$date"},
+		}},
+	)
+	cur, err := db.MongoDB.Collection("fights").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var fights []struct {
+		Slug        string    `bson:"_id"`
+		Fights      int       `bson:"fights"`
+		TitleFights int       `bson:"title_fights"`
+		LastFight   time.Time `bson:"last_fight"`
+	}
+	if err := cur.All(ctx, &fights); err != nil {
+		return nil, err
+	}
+
+	cur, err = db.MongoDB.Collection("fighterDivisions").Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{"_id": "$current", "fighters": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var active []struct {
+		Slug     string `bson:"_id"`
+		Fighters int    `bson:"fighters"`
+	}
+	if err := cur.All(ctx, &active); err != nil {
+		return nil, err
+	}
+
+	summaries := make([]DivisionSummary, len(data.Divisions))
+	index := make(map[string]int, len(data.Divisions))
+	for i, d := range data.Divisions {
+		summaries[i] = DivisionSummary{Division: d}
+		index[d.Slug] = i
+	}
+	for _, f := range fights {
+		if i, ok := index[f.Slug]; ok {
+			summaries[i].Fights = f.Fights
+			summaries[i].TitleFights = f.TitleFights
+			if !f.LastFight.IsZero() {
+				last := f.LastFight
+				summaries[i].LastFight = &last
+			}
+		}
+	}
+	for _, a := range active {
+		if i, ok := index[a.Slug]; ok {
+			summaries[i].ActiveFighters = a.Fighters
+		}
+	}
+
+	return summaries, nil
+}
+
+// the division in the {slug} path param, false after writing a 404
+func divisionFromPath(w http.ResponseWriter, r *http.Request) (data.Division, bool) {
+	d, ok := data.DivisionBySlug(chi.URLParam(r, "slug"))
+	if !ok {
+		render.Status(r, 404)
+		render.PlainText(w, r, "division not found")
+	}
+	return d, ok
+}
+
+// ids of the fighters in a division, by their current (default) or primary division
+func divisionFighterIDs(ctx context.Context, slug, by string) ([]string, error) {
+	ids := []string{}
+	err := db.MongoDB.Collection("fighterDivisions").Distinct(ctx, "_id", bson.M{by: slug}).Decode(&ids)
+	return ids, err
+}
+
+// ?by=current|primary, which of a fighter's divisions to match
+func divisionFieldFromQuery(r *http.Request) (string, error) {
+	switch by := r.URL.Query().Get("by"); by {
+	case "", "current":
+		return "current", nil
+	case "primary":
+		return "primary", nil
+	default:
+		return "", fmt.Errorf("by must be current or primary, got %q", by)
+	}
+}
+
+// fighters competing in the division (?by=primary for their primary division instead of the current one)
+func ListDivisionFighters(w http.ResponseWriter, r *http.Request) {
+	d, ok := divisionFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	by, err := divisionFieldFromQuery(r)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+
+	ids, err := divisionFighterIDs(r.Context(), d.Slug, by)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	page, ok := db.List[data.Fighter](w, r, "fighters", bson.M{"_id": bson.M{"$in": ids}}, fighterSorts, bson.D{{Key: "_id", Value: 1}})
+	if !ok {
+		return
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.Fighters{Items: page.Items})
+}
+
+// fights contested in the division
+func ListDivisionFights(w http.ResponseWriter, r *http.Request) {
+	d, ok := divisionFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	listFights(w, r, bson.M{"division": d.Slug}, fightsByID)
+}
+
+// primary_division and current_division of a fighter, nil when they have no fights
+func fighterDivisionEmbed(ctx context.Context, fighterID string) (map[string]any, error) {
+	var fd data.FighterDivision
+	err := db.MongoDB.Collection("fighterDivisions").FindOne(ctx, bson.M{"_id": fighterID}).Decode(&fd)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return map[string]any{"primary_division": fd.Primary, "current_division": fd.Current}, nil
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ?expand=fights.fighters is two levels deep, nothing deeper is accepted so an expansion is at most
+// one batched query per level
+const maxExpandDepth = 2
+
+// relations accepted by ?expand= on each resource
+var (
+	fightExpands = []string{"event", "fighters"}
+	eventExpands = []string{"fights", "fights.event", "fights.fighters"}
+)
+
+// parse ?expand=event,fighters. a nested expansion brings its parent along (fights.fighters expands fights
+// too). on failure the error response is written and ok is false
+func expandFromQuery(w http.ResponseWriter, r *http.Request, allowed []string) (expand []string, ok bool) {
+	v := r.URL.Query().Get("expand")
+	if v == "" {
+		return nil, true
+	}
+
+	for _, e := range strings.Split(v, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if strings.Count(e, ".")+1 > maxExpandDepth {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("expand %q is nested more than %d levels", e, maxExpandDepth)))
+			return nil, false
+		}
+		if !slices.Contains(allowed, e) {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("cannot expand %q", e)))
+			return nil, false
+		}
+		expand = append(expand, e)
+
+		for i, c := range e {
+			if c == '.' && !slices.Contains(expand, e[:i]) {
+				expand = append(expand, e[:i])
+			}
+		}
+	}
+
+	return expand, true
+}
+
+// the expansions below 'name', i.e. fights.fighters -> fighters
+func subExpand(expand []string, name string) []string {
+	var sub []string
+	for _, e := range expand {
+		if rest, ok := strings.CutPrefix(e, name+"."); ok {
+			sub = append(sub, rest)
+		}
+	}
+	return sub
+}
+
+// the related documents of each fight (one $in query per relation for the whole slice)
+func fightEmbeds(ctx context.Context, fights []data.Fight, expand []string) ([]map[string]any, error) {
+	embeds := make([]map[string]any, len(fights))
+	for i := range embeds {
+		embeds[i] = map[string]any{}
+	}
+
+	if slices.Contains(expand, "event") {
+		ids := make([]string, 0, len(fights))
+		for _, f := range fights {
+			ids = append(ids, f.EventID)
+		}
+
+		events, err := findByID[data.Event](ctx, "events", ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, f := range fights {
+			if e, ok := events[f.EventID]; ok {
+				embeds[i]["event"] = e
+			} else {
+				embeds[i]["event"] = nil
+			}
+		}
+	}
+
+	if slices.Contains(expand, "fighters") {
+		ids := make([]string, 0, len(fights)*2)
+		for _, f := range fights {
+			for _, p := range f.Participants {
+				ids = append(ids, p.FighterID)
+			}
+		}
+
+		fighters, err := findByID[data.Fighter](ctx, "fighters", ids)
+		if err != nil {
+			return nil, err
+		}
+
+		// same order as participants, fighters that are not stored are left out
+		for i, f := range fights {
+			list := make([]data.Fighter, 0, len(f.Participants))
+			for _, p := range f.Participants {
+				if fighter, ok := fighters[p.FighterID]; ok {
+					list = append(list, fighter)
+				}
+			}
+			embeds[i]["fighters"] = list
+		}
+	}
+
+	return embeds, nil
+}
+
+// the related documents of each event
+func eventEmbeds(ctx context.Context, events []data.Event, expand []string) ([]map[string]any, error) {
+	embeds := make([]map[string]any, len(events))
+	for i := range embeds {
+		embeds[i] = map[string]any{}
+	}
+
+	if !slices.Contains(expand, "fights") {
+		return embeds, nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	// each card in its order, the same one ListEventFights defaults to
+	opts := options.Find().SetSort(append(slices.Clone(cardOrder), bson.E{Key: "_id", Value: 1}))
+	cur, err := db.MongoDB.Collection("fights").Find(ctx, bson.M{"event_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var fights []data.Fight
+	if err := cur.All(ctx, &fights); err != nil {
+		return nil, err
+	}
+
+	// the fights get their own expansions (fights.fighters) in one go for every event
+	var nested []map[string]any
+	if sub := subExpand(expand, "fights"); len(sub) > 0 {
+		if nested, err = fightEmbeds(ctx, fights, sub); err != nil {
+			return nil, err
+		}
+	}
+
+	byEvent := make(map[string][]any, len(events))
+	for i, f := range fights {
+		if nested == nil {
+			byEvent[f.EventID] = append(byEvent[f.EventID], f)
+			continue
+		}
+
+		doc, err := db.Prune(f, nil)
+		if err != nil {
+			return nil, err
+		}
+		m := doc.(map[string]any)
+		for k, v := range nested[i] {
+			m[k] = v
+		}
+		byEvent[f.EventID] = append(byEvent[f.EventID], m)
+	}
+
+	for i, e := range events {
+		if list, ok := byEvent[e.ID]; ok {
+			embeds[i]["fights"] = list
+		} else {
+			embeds[i]["fights"] = []any{}
+		}
+	}
+
+	return embeds, nil
+}
+
+// documents of a collection by _id, one $in query
+func findByID[T any, PT interface {
+	*T
+	data.IDable
+}](ctx context.Context, collName string, ids []string) (map[string]T, error) {
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	cur, err := db.MongoDB.Collection(collName).Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []T
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]T, len(docs))
+	for i := range docs {
+		out[PT(&docs[i]).GetID()] = docs[i]
+	}
+	return out, nil
+}
+
+// default orders of the fight lists. a fighter's fights read newest first, an event's fights in card order
+// (main event first, fights stored before the bout position was scraped sort by id)
+var (
+	fightsByID        = bson.D{{Key: "_id", Value: 1}}
+	newestFightsFirst = bson.D{{Key: "date", Value: -1}}
+	cardOrder         = bson.D{{Key: "bout", Value: 1}}
+)
+
+// the shared tail of every fight list endpoint: paginate, expand and render
+func listFights(w http.ResponseWriter, r *http.Request, filter bson.M, def bson.D) {
+	expand, ok := expandFromQuery(w, r, fightExpands)
+	if !ok {
+		return
+	}
+	if expand != nil {
+		r = db.WithFields(r, "event_id", "participants.fighter_id")
+	}
+
+	page, ok := db.List[data.Fight](w, r, "fights", filter, fightSorts, def)
+	if !ok {
+		return
+	}
+
+	if expand != nil {
+		embeds, err := fightEmbeds(r.Context(), page.Items, expand)
+		if err != nil {
+			render.Status(r, 500)
+			render.PlainText(w, r, "db error")
+			return
+		}
+		page.Embed(embeds)
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.Fights{Items: page.Items})
+}
+
+// the shared tail of every event list endpoint
+func listEvents(w http.ResponseWriter, r *http.Request, filter bson.M, def bson.D) {
+	expand, ok := expandFromQuery(w, r, eventExpands)
+	if !ok {
+		return
+	}
+
+	page, ok := db.List[data.Event](w, r, "events", filter, eventSorts, def)
+	if !ok {
+		return
+	}
+
+	if expand != nil {
+		embeds, err := eventEmbeds(r.Context(), page.Items, expand)
+		if err != nil {
+			render.Status(r, 500)
+			render.PlainText(w, r, "db error")
+			return
+		}
+		page.Embed(embeds)
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.Events{Items: page.Items})
+}
@@ -1,19 +1,75 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
 	"github.com/anthonybliss1/ufc-api/api/db"
 	"github.com/anthonybliss1/ufc-api/api/pkg"
 	"github.com/anthonybliss1/ufc-api/scrape/data"
 	"github.com/go-chi/render"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// fields accepted by ?sort= on each resource (name -> document path)
+var (
+	fightSorts = db.SortFields{
+		"id":       "_id",
+		"event_id": "event_id",
+		"date":     "date", // of the event
+		"bout":     "bout", // position on the card
+		"method":   "method",
+		"round":    "round",
+		"referee":  "referee",
+	}
+
+	fighterSorts = db.SortFields{
+		"id":                   "_id",
+		"name":                 "name",
+		"nickname":             "nickname",
+		"stance":               "stance",
+		"dob":                  "dob",
+		"career_stats.slpm":    "career_stats.slpm",
+		"career_stats.sapm":    "career_stats.sapm",
+		"career_stats.td_avg":  "career_stats.td_avg",
+		"career_stats.sub_avg": "career_stats.sub_avg",
+	}
+
+	// shared by events and upcoming events
+	eventSorts = db.SortFields{
+		"id":       "_id",
+		"name":     "name",
+		"date":     "date",
+		"location": "location",
+	}
+
+	upcomingFightSorts = db.SortFields{
+		"id":                "_id",
+		"upcoming_event_id": "upcoming_event_id",
+	}
+
+	versionSorts = db.SortFields{
+		"valid_from": "valid_from",
+	}
+)
+
 func ListFights(w http.ResponseWriter, r *http.Request) {
+	filter, ok := fightFilterFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	listFights(w, r, filter, fightsByID)
+}
+
+// the /fights filters, shared with /stats/aggregate. false after writing an error
+func fightFilterFromQuery(w http.ResponseWriter, r *http.Request) (bson.M, bool) {
 	q := r.URL.Query()
 	and := bson.A{}
 
@@ -36,44 +92,27 @@ func ListFights(w http.ResponseWriter, r *http.Request) {
 			})
 		}
 	}
-
-	filter := bson.M{}
-	if len(and) > 0 {
-		filter["$and"] = and
-	}
-
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
+	if v := q.Get("division"); v != "" {
+		and = append(and, bson.M{"division": v})
 	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "_id", Value: 1}})
-
-	cur, err := db.MongoDB.Collection("fights").Find(r.Context(), filter, opts)
+	// ?method_category=SUBMISSION&technique=Rear Naked Choke
+	category, err := methodCategoryFromQuery(r)
 	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
-		return
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return nil, false
 	}
-	defer cur.Close(r.Context())
-
-	var items []data.Fight
-	for cur.Next(r.Context()) {
-		var f data.Fight
-		if err := cur.Decode(&f); err != nil {
-			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
-			return
-		}
-		items = append(items, f)
+	if category != "" {
+		and = append(and, bson.M{"method_category": category})
 	}
-
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
+	if v := q.Get("technique"); v != "" {
+		and = append(and, bson.M{"technique": v})
 	}
 
-	db.CacheFor(w, 30*time.Second)
-
-	db.RenderJSON(w, r, data.Fights{Items: items})
+	filter := bson.M{}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	return filter, true
 }
 
 func SearchFights(w http.ResponseWriter, r *http.Request) {
@@ -94,51 +133,54 @@ func SearchFights(w http.ResponseWriter, r *http.Request) {
 		filter["$and"] = and
 	}
 
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
+	listFights(w, r, filter, fightsByID)
+}
+
+func GetFight(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxFightKey).(*data.Fight)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fight not found")
+		return
 	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "_id", Value: 1}})
 
-	cur, err := db.MongoDB.Collection("fights").Find(r.Context(), filter, opts)
-	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
+	expand, ok := expandFromQuery(w, r, fightExpands)
+	if !ok {
 		return
 	}
-	defer cur.Close(r.Context())
 
-	var items []data.Fight
-	for cur.Next(r.Context()) {
-		var f data.Fight
-		if err := cur.Decode(&f); err != nil {
+	var embeds map[string]any
+	if expand != nil {
+		all, err := fightEmbeds(r.Context(), []data.Fight{*f}, expand)
+		if err != nil {
 			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
+			render.PlainText(w, r, "db error")
 			return
 		}
-		items = append(items, f)
-	}
-
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
+		embeds = all[0]
 	}
 
-	db.CacheFor(w, 30*time.Second)
-
-	db.RenderJSON(w, r, data.Fights{Items: items})
+	db.RenderFields(w, r, f, embeds)
 }
 
-func GetFight(w http.ResponseWriter, r *http.Request) {
-	f, _ := r.Context().Value(pkg.CtxFightKey).(*data.Fight)
-	if f == nil {
-		render.Status(r, 404)
-		render.PlainText(w, r, "fight not found")
+func ListFighters(w http.ResponseWriter, r *http.Request) {
+	filter, ok := fighterFilterFromQuery(w, r)
+	if !ok {
 		return
 	}
-	db.RenderJSON(w, r, f)
+
+	page, ok := db.List[data.Fighter](w, r, "fighters", filter, fighterSorts, bson.D{{Key: "_id", Value: 1}})
+	if !ok {
+		return
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.Fighters{Items: page.Items})
 }
 
-func ListFighters(w http.ResponseWriter, r *http.Request) {
+// the /fighters filters, shared with /stats/aggregate. false after writing an error
+func fighterFilterFromQuery(w http.ResponseWriter, r *http.Request) (bson.M, bool) {
 	filter := bson.M{}
 	q := r.URL.Query()
 
@@ -167,39 +209,22 @@ func ListFighters(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
-	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "_id", Value: 1}})
-
-	cur, err := db.MongoDB.Collection("fighters").Find(r.Context(), filter, opts)
-	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
-		return
-	}
-	defer cur.Close(r.Context())
-
-	var items []data.Fighter
-	for cur.Next(r.Context()) {
-		var f data.Fighter
-		if err := cur.Decode(&f); err != nil {
+	// ?division=lightweight matches the fighter's current division, &by=primary their primary one
+	if v := q.Get("division"); v != "" {
+		by, err := divisionFieldFromQuery(r)
+		if err != nil {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+			return nil, false
+		}
+		ids, err := divisionFighterIDs(r.Context(), v, by)
+		if err != nil {
 			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
-			return
+			render.PlainText(w, r, "db error")
+			return nil, false
 		}
-		items = append(items, f)
-	}
-
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
+		filter["_id"] = bson.M{"$in": ids}
 	}
-
-	db.CacheFor(w, 30*time.Second)
-
-	db.RenderJSON(w, r, data.Fighters{Items: items})
+	return filter, true
 }
 
 func SearchFighters(w http.ResponseWriter, r *http.Request) {
@@ -217,48 +242,177 @@ func SearchFighters(w http.ResponseWriter, r *http.Request) {
 	if len(and) > 0 {
 		filter["$and"] = and
 	}
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
+	page, ok := db.List[data.Fighter](w, r, "fighters", filter, fighterSorts, bson.D{{Key: "_id", Value: 1}})
+	if !ok {
+		return
 	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "_id", Value: 1}})
 
-	cur, err := db.MongoDB.Collection("fighters").Find(r.Context(), filter, opts)
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.Fighters{Items: page.Items})
+}
+
+func GetFighter(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter not found")
+		return
+	}
+
+	// ?as_of=2022-01-01 returns the version of the fighter that was current on that date
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		asOf, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("as_of must be YYYY-MM-DD: %v", err)))
+			return
+		}
+
+		opts := options.FindOne().SetSort(bson.D{{Key: "valid_from", Value: -1}})
+		filter := bson.M{"fighter_id": f.ID, "valid_from": bson.M{"$lte": asOf}}
+
+		var version data.FighterVersion
+		if err := db.MongoDB.Collection("fighterVersions").FindOne(r.Context(), filter, opts).Decode(&version); err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				render.Status(r, 500)
+				render.PlainText(w, r, "db error")
+				return
+			}
+			fighterHistoryNotFound(w, r, f.ID)
+			return
+		}
+
+		w.Header().Set("X-Version-ID", version.ID)
+		db.RenderFields(w, r, version.Fighter, nil)
+		return
+	}
+
+	embeds, err := fighterDivisionEmbed(r.Context(), f.ID)
 	if err != nil {
 		render.Status(r, 500)
 		render.PlainText(w, r, "db error")
 		return
 	}
-	defer cur.Close(r.Context())
 
-	var items []data.Fighter
-	for cur.Next(r.Context()) {
-		var f data.Fighter
-		if err := cur.Decode(&f); err != nil {
-			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
-			return
+	streaks, err := fighterStreaks(r.Context(), f.ID)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+	if streaks != nil {
+		if embeds == nil {
+			embeds = map[string]any{}
 		}
-		items = append(items, f)
+		embeds["streaks"] = streaks
+	}
+
+	db.RenderFields(w, r, f, embeds)
+}
+
+// 404 for an as_of before the fighter's first archived version, saying where their history starts. the
+// current profile is never returned in its place since nothing says it was valid back then
+func fighterHistoryNotFound(w http.ResponseWriter, r *http.Request, fighterID string) {
+	var first data.FighterVersion
+	opts := options.FindOne().SetSort(bson.D{{Key: "valid_from", Value: 1}}).SetProjection(bson.M{"valid_from": 1})
+	err := db.MongoDB.Collection("fighterVersions").FindOne(r.Context(), bson.M{"fighter_id": fighterID}, opts).Decode(&first)
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter has no history")
+	case err != nil:
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+	default:
+		render.Status(r, 404)
+		render.PlainText(w, r, fmt.Sprintf("no history before %s", first.ValidFrom.Format("2006-01-02")))
+	}
+}
+
+// every archived version of the fighter, newest first
+func GetFighterHistory(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter not found")
+		return
 	}
 
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
+	filter := bson.M{"fighter_id": f.ID}
+
+	page, ok := db.List[data.FighterVersion](w, r, "fighterVersions", filter, versionSorts, bson.D{{Key: "valid_from", Value: -1}})
+	if !ok {
+		return
 	}
 
 	db.CacheFor(w, 30*time.Second)
 
-	db.RenderJSON(w, r, data.Fighters{Items: items})
+	db.RenderPage(w, r, page, data.FighterVersions{Items: page.Items})
 }
 
-func GetFighter(w http.ResponseWriter, r *http.Request) {
+// every fight the fighter took part in
+func ListFighterFights(w http.ResponseWriter, r *http.Request) {
 	f, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
 	if f == nil {
 		render.Status(r, 404)
 		render.PlainText(w, r, "fighter not found")
 		return
 	}
-	db.RenderJSON(w, r, f)
+
+	filter := bson.M{"participants.fighter_id": f.ID}
+
+	listFights(w, r, filter, newestFightsFirst)
+}
+
+// every fighter the fighter has faced
+func ListFighterOpponents(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter not found")
+		return
+	}
+
+	var ids []string
+	err := db.MongoDB.Collection("fights").Distinct(r.Context(), "participants.fighter_id", bson.M{"participants.fighter_id": f.ID}).Decode(&ids)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	opponents := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != f.ID && id != "" {
+			opponents = append(opponents, id)
+		}
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": opponents}}
+
+	page, ok := db.List[data.Fighter](w, r, "fighters", filter, fighterSorts, bson.D{{Key: "_id", Value: 1}})
+	if !ok {
+		return
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.Fighters{Items: page.Items})
+}
+
+// scheduled matchups the fighter is on
+func ListFighterUpcoming(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter not found")
+		return
+	}
+
+	filter := bson.M{"tale_of_the_tape._id": f.ID}
+
+	listUpcomingFights(w, r, filter)
 }
 
 func parseFloat32(s string) float32 {
@@ -291,38 +445,7 @@ func ListEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
-	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "date", Value: -1}})
-
-	cur, err := db.MongoDB.Collection("events").Find(r.Context(), filter, opts)
-	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
-		return
-	}
-	defer cur.Close(r.Context())
-
-	var items []data.Event
-	for cur.Next(r.Context()) {
-		var e data.Event
-		if err := cur.Decode(&e); err != nil {
-			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
-			return
-		}
-		items = append(items, e)
-	}
-
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
-	}
-
-	db.CacheFor(w, 30*time.Second)
-
-	db.RenderJSON(w, r, data.Events{Items: items})
+	listEvents(w, r, filter, bson.D{{Key: "date", Value: -1}})
 }
 
 func SearchEvents(w http.ResponseWriter, r *http.Request) {
@@ -341,48 +464,48 @@ func SearchEvents(w http.ResponseWriter, r *http.Request) {
 		filter["$and"] = and
 	}
 
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
+	listEvents(w, r, filter, bson.D{{Key: "date", Value: -1}})
+}
+
+func GetEvent(w http.ResponseWriter, r *http.Request) {
+	e, _ := r.Context().Value(pkg.CtxEventKey).(*data.Event)
+	if e == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "event not found")
+		return
 	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "date", Value: -1}})
 
-	cur, err := db.MongoDB.Collection("events").Find(r.Context(), filter, opts)
-	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
+	expand, ok := expandFromQuery(w, r, eventExpands)
+	if !ok {
 		return
 	}
-	defer cur.Close(r.Context())
 
-	var items []data.Event
-	for cur.Next(r.Context()) {
-		var e data.Event
-		if err := cur.Decode(&e); err != nil {
+	var embeds map[string]any
+	if expand != nil {
+		all, err := eventEmbeds(r.Context(), []data.Event{*e}, expand)
+		if err != nil {
 			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
+			render.PlainText(w, r, "db error")
 			return
 		}
-		items = append(items, e)
+		embeds = all[0]
 	}
 
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
-	}
-
-	db.CacheFor(w, 30*time.Second)
-
-	db.RenderJSON(w, r, data.Events{Items: items})
+	db.RenderFields(w, r, e, embeds)
 }
 
-func GetEvent(w http.ResponseWriter, r *http.Request) {
+// the fights on the card of the event
+func ListEventFights(w http.ResponseWriter, r *http.Request) {
 	e, _ := r.Context().Value(pkg.CtxEventKey).(*data.Event)
 	if e == nil {
 		render.Status(r, 404)
 		render.PlainText(w, r, "event not found")
 		return
 	}
-	db.RenderJSON(w, r, e)
+
+	filter := bson.M{"event_id": e.ID}
+
+	listFights(w, r, filter, cardOrder)
 }
 
 func ListUpcomingEvents(w http.ResponseWriter, r *http.Request) {
@@ -408,38 +531,14 @@ func ListUpcomingEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
-	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "date", Value: 1}})
-
-	cur, err := db.MongoDB.Collection("upcomingEvents").Find(r.Context(), filter, opts)
-	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
+	page, ok := db.List[data.UpcomingEvent](w, r, "upcomingEvents", filter, eventSorts, bson.D{{Key: "date", Value: 1}})
+	if !ok {
 		return
 	}
-	defer cur.Close(r.Context())
-
-	var items []data.UpcomingEvent
-	for cur.Next(r.Context()) {
-		var e data.UpcomingEvent
-		if err := cur.Decode(&e); err != nil {
-			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
-			return
-		}
-		items = append(items, e)
-	}
-
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
-	}
 
 	db.CacheFor(w, 30*time.Second)
 
-	db.RenderJSON(w, r, data.UpcomingEvents{Items: items})
+	db.RenderPage(w, r, page, data.UpcomingEvents{Items: page.Items})
 }
 
 func SearchUpcomingEvents(w http.ResponseWriter, r *http.Request) {
@@ -458,38 +557,14 @@ func SearchUpcomingEvents(w http.ResponseWriter, r *http.Request) {
 		filter["$and"] = and
 	}
 
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
-	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "date", Value: -1}})
-
-	cur, err := db.MongoDB.Collection("upcomingEvents").Find(r.Context(), filter, opts)
-	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
+	page, ok := db.List[data.UpcomingEvent](w, r, "upcomingEvents", filter, eventSorts, bson.D{{Key: "date", Value: -1}})
+	if !ok {
 		return
 	}
-	defer cur.Close(r.Context())
-
-	var items []data.UpcomingEvent
-	for cur.Next(r.Context()) {
-		var e data.UpcomingEvent
-		if err := cur.Decode(&e); err != nil {
-			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
-			return
-		}
-		items = append(items, e)
-	}
-
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
-	}
 
 	db.CacheFor(w, 30*time.Second)
 
-	db.RenderJSON(w, r, data.UpcomingEvents{Items: items})
+	db.RenderPage(w, r, page, data.UpcomingEvents{Items: page.Items})
 }
 
 func GetUpcomingEvent(w http.ResponseWriter, r *http.Request) {
@@ -499,7 +574,21 @@ func GetUpcomingEvent(w http.ResponseWriter, r *http.Request) {
 		render.PlainText(w, r, "event not found")
 		return
 	}
-	db.RenderJSON(w, r, e)
+	db.RenderFields(w, r, e, nil)
+}
+
+// the matchups scheduled for the upcoming event
+func ListUpcomingEventFights(w http.ResponseWriter, r *http.Request) {
+	e, _ := r.Context().Value(pkg.CtxUpcomingEventKey).(*data.UpcomingEvent)
+	if e == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "event not found")
+		return
+	}
+
+	filter := bson.M{"upcoming_event_id": e.ID}
+
+	listUpcomingFights(w, r, filter)
 }
 
 func ListUpcomingFights(w http.ResponseWriter, r *http.Request) {
@@ -523,38 +612,7 @@ func ListUpcomingFights(w http.ResponseWriter, r *http.Request) {
 		filter["$and"] = and
 	}
 
-	limit := db.LimitFromQuery(r, 50, 50)
-	if after := db.AfterFromQuery(r); after != "" {
-		filter["_id"] = bson.M{"$gt": after}
-	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "_id", Value: 1}})
-
-	cur, err := db.MongoDB.Collection("upcomingFights").Find(r.Context(), filter, opts)
-	if err != nil {
-		render.Status(r, 500)
-		render.PlainText(w, r, "db error")
-		return
-	}
-	defer cur.Close(r.Context())
-
-	var items []data.UpcomingFight
-	for cur.Next(r.Context()) {
-		var f data.UpcomingFight
-		if err := cur.Decode(&f); err != nil {
-			render.Status(r, 500)
-			render.PlainText(w, r, "decode error")
-			return
-		}
-		items = append(items, f)
-	}
-
-	if n := len(items); n > 0 {
-		w.Header().Set("X-Next-After", items[n-1].ID)
-	}
-
-	db.CacheFor(w, 30*time.Second)
-
-	db.RenderJSON(w, r, data.UpcomingFights{Items: items})
+	listUpcomingFights(w, r, filter)
 }
 
 func GetUpcomingFight(w http.ResponseWriter, r *http.Request) {
@@ -564,5 +622,16 @@ func GetUpcomingFight(w http.ResponseWriter, r *http.Request) {
 		render.PlainText(w, r, "fight not found")
 		return
 	}
-	db.RenderJSON(w, r, f)
+
+	embeds, err := upcomingFightEmbeds(r.Context(), []data.UpcomingFight{*f})
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+	var embed map[string]any
+	if embeds != nil {
+		embed = embeds[0]
+	}
+	db.RenderFields(w, r, f, embed)
 }
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/api/pkg"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// one fighter's numbers summed over a set of fights
+type FightTotals struct {
+	FighterID   string `json:"fighter_id"`
+	Fights      int    `json:"fights"`
+	Wins        int    `json:"wins"`
+	Losses      int    `json:"losses"`
+	Draws       int    `json:"draws"`
+	KD          int    `json:"kd"`
+	SigStrL     int    `json:"sig_str_landed"`
+	SigStrA     int    `json:"sig_str_attempted"`
+	TotalStrL   int    `json:"total_str_landed"`
+	TotalStrA   int    `json:"total_str_attempted"`
+	TdL         int    `json:"td_landed"`
+	TdA         int    `json:"td_attempted"`
+	Sub         int    `json:"sub"`
+	Rev         int    `json:"rev"`
+	CtrlSeconds int    `json:"ctrl_seconds"`
+	HeadL       int    `json:"head_landed"`
+	BodyL       int    `json:"body_landed"`
+	LegL        int    `json:"leg_landed"`
+	DistanceL   int    `json:"distance_landed"`
+	ClinchL     int    `json:"clinch_landed"`
+	GroundL     int    `json:"ground_landed"`
+}
+
+func (t *FightTotals) add(p data.FightStats) {
+	t.Fights++
+	switch p.Outcome {
+	case "W":
+		t.Wins++
+	case "L":
+		t.Losses++
+	case "D":
+		t.Draws++
+	}
+	t.KD += p.KD
+	t.SigStrL += p.SigStrL
+	t.SigStrA += p.SigStrA
+	t.TotalStrL += p.TotalStrL
+	t.TotalStrA += p.TotalStrA
+	t.TdL += p.TdL
+	t.TdA += p.TdA
+	t.Sub += p.Sub
+	t.Rev += p.Rev
+	t.CtrlSeconds += data.ClockSeconds(p.Ctrl)
+	t.HeadL += p.HeadL
+	t.BodyL += p.BodyL
+	t.LegL += p.LegL
+	t.DistanceL += p.DistanceL
+	t.ClinchL += p.ClinchL
+	t.GroundL += p.GroundL
+}
+
+// one row of the side by side comparison, A is the fighter in the path, B the opponent
+type ComparisonRow struct {
+	Field string `json:"field"`
+	A     any    `json:"a"`
+	B     any    `json:"b"`
+}
+
+// how a fighter did against an opponent in one fight
+type FightResult struct {
+	FightID string `json:"fight_id"`
+	EventID string `json:"event_id"`
+	Outcome string `json:"outcome"`
+	Method  string `json:"method"`
+	Round   int    `json:"round"`
+}
+
+// an opponent both fighters have faced and their results against them
+type CommonOpponent struct {
+	OpponentID   string        `json:"opponent_id"`
+	OpponentName string        `json:"opponent_name"`
+	A            []FightResult `json:"a"`
+	B            []FightResult `json:"b"`
+}
+
+// response of /fighters/{a}/vs/{b}
+type HeadToHead struct {
+	Fighters        [2]data.Fighter  `json:"fighters"`
+	Comparison      []ComparisonRow  `json:"comparison"`
+	Fights          []data.Fight     `json:"fights"`
+	Totals          [2]FightTotals   `json:"totals"`                     // each side summed over the fights between them
+	CommonOpponents []CommonOpponent `json:"common_opponents,omitempty"` // only when they never met
+}
+
+// fights between the fighter and {opponentID}, or their common opponents when they never met
+func GetHeadToHead(w http.ResponseWriter, r *http.Request) {
+	a, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
+	if a == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter not found")
+		return
+	}
+
+	if chi.URLParam(r, "opponentID") == a.ID {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("a fighter cannot be compared with themselves")))
+		return
+	}
+
+	var b data.Fighter
+	if err := db.MongoDB.Collection("fighters").FindOne(r.Context(), bson.M{"_id": chi.URLParam(r, "opponentID")}).Decode(&b); err != nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "opponent not found")
+		return
+	}
+
+	filter := bson.M{"$and": bson.A{
+		bson.M{"participants.fighter_id": a.ID},
+		bson.M{"participants.fighter_id": b.ID},
+	}}
+
+	fights, err := findFights(r.Context(), filter)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	h2h := HeadToHead{
+		Fighters:   [2]data.Fighter{*a, b},
+		Comparison: compareFighters(*a, b),
+		Fights:     fights,
+		Totals:     [2]FightTotals{{FighterID: a.ID}, {FighterID: b.ID}},
+	}
+
+	for _, f := range fights {
+		for _, p := range f.Participants {
+			switch p.FighterID {
+			case a.ID:
+				h2h.Totals[0].add(p)
+			case b.ID:
+				h2h.Totals[1].add(p)
+			}
+		}
+	}
+
+	if len(fights) == 0 {
+		if h2h.CommonOpponents, err = commonOpponents(r.Context(), a.ID, b.ID); err != nil {
+			render.Status(r, 500)
+			render.PlainText(w, r, "db error")
+			return
+		}
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderJSON(w, r, h2h)
+}
+
+func findFights(ctx context.Context, filter bson.M) ([]data.Fight, error) {
+	cur, err := db.MongoDB.Collection("fights").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	fights := []data.Fight{}
+	if err := cur.All(ctx, &fights); err != nil {
+		return nil, err
+	}
+	return fights, nil
+}
+
+// physicals and career stats of both fighters as rows
+func compareFighters(a, b data.Fighter) []ComparisonRow {
+	return []ComparisonRow{
+		{"current_record", a.CurrentRecord, b.CurrentRecord},
+		{"height", a.Height, b.Height},
+		{"weight_lb", a.WeightLB, b.WeightLB},
+		{"reach_in", a.ReachIN, b.ReachIN},
+		{"stance", a.Stance, b.Stance},
+		{"dob", a.DOB, b.DOB},
+		{"career_stats.slpm", a.CareerStats.SLpM, b.CareerStats.SLpM},
+		{"career_stats.str_acc", a.CareerStats.StrAcc, b.CareerStats.StrAcc},
+		{"career_stats.sapm", a.CareerStats.SApM, b.CareerStats.SApM},
+		{"career_stats.str_def", a.CareerStats.StrDef, b.CareerStats.StrDef},
+		{"career_stats.td_avg", a.CareerStats.TdAvg, b.CareerStats.TdAvg},
+		{"career_stats.td_acc", a.CareerStats.TdAcc, b.CareerStats.TdAcc},
+		{"career_stats.td_def", a.CareerStats.TdDef, b.CareerStats.TdDef},
+		{"career_stats.sub_avg", a.CareerStats.SubAvg, b.CareerStats.SubAvg},
+	}
+}
+
+// opponents both fighters have faced with each one's results against them, ordered by opponent name
+func commonOpponents(ctx context.Context, aID, bID string) ([]CommonOpponent, error) {
+	fights, err := findFights(ctx, bson.M{"participants.fighter_id": bson.M{"$in": bson.A{aID, bID}}})
+	if err != nil {
+		return nil, err
+	}
+
+	// opponent id -> results of a and b against them
+	results := make(map[string]*CommonOpponent)
+	for _, f := range fights {
+		if len(f.Participants) != 2 {
+			continue
+		}
+
+		for i, p := range f.Participants {
+			if p.FighterID != aID && p.FighterID != bID {
+				continue
+			}
+			opp := f.Participants[1-i]
+
+			c, ok := results[opp.FighterID]
+			if !ok {
+				c = &CommonOpponent{OpponentID: opp.FighterID, OpponentName: opp.FighterName, A: []FightResult{}, B: []FightResult{}}
+				results[opp.FighterID] = c
+			}
+
+			res := FightResult{FightID: f.ID, EventID: f.EventID, Outcome: p.Outcome, Method: f.Method, Round: f.Round}
+			if p.FighterID == aID {
+				c.A = append(c.A, res)
+			} else {
+				c.B = append(c.B, res)
+			}
+		}
+	}
+
+	common := make([]CommonOpponent, 0)
+	for _, c := range results {
+		if len(c.A) > 0 && len(c.B) > 0 {
+			common = append(common, *c)
+		}
+	}
+	slices.SortFunc(common, func(x, y CommonOpponent) int {
+		if x.OpponentName != y.OpponentName {
+			return cmp.Compare(x.OpponentName, y.OpponentName)
+		}
+		return cmp.Compare(x.OpponentID, y.OpponentID)
+	})
+
+	return common, nil
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// leaderboard scopes
+const (
+	ScopeCareer = "career" // totals and rates over every fight of a fighter
+	ScopeFight  = "fight"  // a single performance
+)
+
+// how long a leaderboard aggregation may run
+const leadersTimeout = 20 * time.Second
+
+// $divide that yields null instead of failing when the denominator is 0
+func ratio(num, den any) bson.M {
+	return bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{den, 0}}, bson.M{"$divide": bson.A{num, den}}, nil}}
+}
+
+// career stats are expressions over the flat statsGroup output of a fighter. profile ones read the
+// CareerStats of the fighter document (looked up as 'fighter') and ignore the date range
+var careerStats = map[string]any{
+	"fights":           "$fights",
+	"wins":             "$wins",
+	"finishes":         "$finishes",
+	"finish_rate":      ratio("$finishes", "$wins"),
+	"knockdowns":       "$knockdowns",
+	"sig_str_landed":   "$sig_str_landed",
+	"sig_str_accuracy": ratio("$sig_str_landed", "$sig_str_attempted"),
+	"sig_str_per_min":  ratio("$sig_str_landed", bson.M{"$divide": bson.A{"$fight_seconds", 60}}),
+	"td_landed":        "$td_landed",
+	"td_accuracy":      ratio("$td_landed", "$td_attempted"),
+	"td_per_15":        ratio("$td_landed", bson.M{"$divide": bson.A{"$fight_seconds", 900}}),
+	"sub_attempts":     "$sub_attempts",
+	"control_seconds":  "$control_seconds",
+	"fight_seconds":    "$fight_seconds",
+	"slpm":             "$fighter.career_stats.slpm",
+	"sapm":             "$fighter.career_stats.sapm",
+	"td_avg":           "$fighter.career_stats.td_avg",
+	"sub_avg":          "$fighter.career_stats.sub_avg",
+}
+
+var profileStats = []string{"slpm", "sapm", "td_avg", "sub_avg"}
+
+// single fight stats are expressions over the fighter's participants entry ('me')
+var fightStats = map[string]any{
+	"knockdowns":        "$me.kd",
+	"sig_str_landed":    "$me.sig_str_landed",
+	"sig_str_attempted": "$me.sig_str_attempted",
+	"sig_str_accuracy":  ratio("$me.sig_str_landed", "$me.sig_str_attempted"),
+	"total_str_landed":  "$me.total_str_landed",
+	"td_landed":         "$me.td_landed",
+	"sub_attempts":      "$me.sub",
+	"control_seconds":   clockSecondsExpr("$me.ctrl"),
+	"head_landed":       "$me.head_landed",
+	"body_landed":       "$me.body_landed",
+	"leg_landed":        "$me.leg_landed",
+}
+
+// one row of a leaderboard
+type Leader struct {
+	Rank        int        `bson:"-" json:"rank"`
+	FighterID   string     `bson:"fighter_id" json:"fighter_id"`
+	FighterName string     `bson:"fighter_name" json:"fighter_name"`
+	Value       float64    `bson:"value" json:"value"`
+	Fights      int        `bson:"fights,omitempty" json:"fights,omitempty"`     // career scope
+	FightID     string     `bson:"fight_id,omitempty" json:"fight_id,omitempty"` // fight scope
+	EventID     string     `bson:"event_id,omitempty" json:"event_id,omitempty"` // fight scope
+	Date        *time.Time `bson:"date,omitempty" json:"date,omitempty"`         // fight scope
+}
+
+// the query a leaderboard was computed for
+type LeaderQuery struct {
+	Stat        string     `json:"stat"`
+	Scope       string     `json:"scope"`
+	Order       string     `json:"order"`
+	MinFights   int        `json:"min_fights,omitempty"`
+	WeightClass string     `json:"weight_class,omitempty"` // division slug
+	Start       *time.Time `json:"start,omitempty"`
+	End         *time.Time `json:"end,omitempty"`
+	Limit       int64      `json:"limit"`
+}
+
+// response of /leaders
+type Leaderboard struct {
+	LeaderQuery
+	ComputedAt time.Time `json:"computed_at"`
+	Leaders    []Leader  `json:"leaders"`
+}
+
+// leaderboards are recomputed once per scraper load
+var leadersCache = db.NewLoadCache[*Leaderboard]()
+
+// /leaders?stat=knockdowns&scope=career&min_fights=10&weight_class=lightweight&start=2015-01-01&end=2020-12-31
+func GetLeaders(w http.ResponseWriter, r *http.Request) {
+	lq, err := leaderQueryFromRequest(r)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+
+	key := fmt.Sprintf("%+v", lq)
+	board, err := leadersCache.Get(r.Context(), key, func(ctx context.Context) (*Leaderboard, error) {
+		return computeLeaders(ctx, lq)
+	})
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderJSON(w, r, board)
+}
+
+func leaderQueryFromRequest(r *http.Request) (LeaderQuery, error) {
+	q := r.URL.Query()
+	lq := LeaderQuery{
+		Stat:        q.Get("stat"),
+		Scope:       q.Get("scope"),
+		Order:       q.Get("order"),
+		WeightClass: q.Get("weight_class"),
+		Limit:       db.LimitFromQuery(r, 10, 100),
+	}
+
+	if lq.Scope == "" {
+		lq.Scope = ScopeCareer
+	}
+	if lq.Order == "" {
+		lq.Order = "desc"
+	}
+	if lq.Order != "asc" && lq.Order != "desc" {
+		return lq, fmt.Errorf("order must be asc or desc")
+	}
+
+	var stats map[string]any
+	switch lq.Scope {
+	case ScopeCareer:
+		stats = careerStats
+	case ScopeFight:
+		stats = fightStats
+	default:
+		return lq, fmt.Errorf("scope must be %s or %s", ScopeCareer, ScopeFight)
+	}
+	if _, ok := stats[lq.Stat]; !ok {
+		return lq, fmt.Errorf("unknown %s stat %q", lq.Scope, lq.Stat)
+	}
+
+	// checked before it becomes part of the cache key
+	if lq.WeightClass != "" {
+		if _, ok := data.DivisionBySlug(lq.WeightClass); !ok {
+			return lq, fmt.Errorf("unknown weight_class %q", lq.WeightClass)
+		}
+	}
+
+	if v := q.Get("min_fights"); v != "" {
+		if lq.Scope != ScopeCareer {
+			return lq, fmt.Errorf("min_fights only applies to the career scope")
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return lq, fmt.Errorf("min_fights must be a positive number")
+		}
+		lq.MinFights = n
+	}
+
+	for param, dst := range map[string]**time.Time{"start": &lq.Start, "end": &lq.End} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return lq, fmt.Errorf("%s must be YYYY-MM-DD: %v", param, err)
+			}
+			*dst = &t
+		}
+	}
+
+	return lq, nil
+}
+
+func computeLeaders(ctx context.Context, lq LeaderQuery) (*Leaderboard, error) {
+	ctx, cancel := context.WithTimeout(ctx, leadersTimeout)
+	defer cancel()
+
+	dir := -1
+	if lq.Order == "asc" {
+		dir = 1
+	}
+
+	pipeline := bson.A{}
+	if lq.WeightClass != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"division": lq.WeightClass}})
+	}
+
+	if lq.Scope == ScopeFight || lq.Start != nil || lq.End != nil {
+		pipeline = append(pipeline, withEventDate()...)
+		if dates := dateRange(lq.Start, lq.End); dates != nil {
+			pipeline = append(pipeline, bson.M{"$match": bson.M{"date": dates}})
+		}
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$unwind": "$participants"},
+		bson.M{"$set": bson.M{"me": "$participants"}},
+	)
+
+	if lq.Scope == ScopeCareer {
+		group := statsGroup("$me.fighter_id")
+		group["fighter_name"] = bson.M{"$last": "$me.fighter_name"}
+
+		pipeline = append(pipeline,
+			bson.M{"$group": group},
+			bson.M{"$match": bson.M{"fights": bson.M{"$gte": lq.MinFights}}},
+		)
+		if slices.Contains(profileStats, lq.Stat) {
+			pipeline = append(pipeline,
+				bson.M{"$lookup": bson.M{"from": "fighters", "localField": "_id", "foreignField": "_id", "as": "fighter"}},
+				bson.M{"$set": bson.M{"fighter": bson.M{"$first": "$fighter"}}},
+			)
+		}
+		pipeline = append(pipeline,
+			bson.M{"$set": bson.M{"value": careerStats[lq.Stat]}},
+			bson.M{"$match": bson.M{"value": bson.M{"$ne": nil}}},
+			bson.M{"$sort": bson.D{{Key: "value", Value: dir}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": lq.Limit},
+			bson.M{"$project": bson.M{"_id": 0, "fighter_id": "$_id", "fighter_name": 1, "fights": 1, "value": 1}},
+		)
+	} else {
+		pipeline = append(pipeline,
+			bson.M{"$set": bson.M{"value": fightStats[lq.Stat]}},
+			bson.M{"$match": bson.M{"value": bson.M{"$ne": nil}}},
+			bson.M{"$sort": bson.D{{Key: "value", Value: dir}, {Key: "date", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": lq.Limit},
+			bson.M{"$project": bson.M{
+				"_id":          0,
+				"fighter_id":   "$me.fighter_id",
+				"fighter_name": "$me.fighter_name",
+				"fight_id":     "$_id",
+				"event_id":     1,
+				"date":         1,
+				"value":        1,
+			}},
+		)
+	}
+
+	cur, err := db.MongoDB.Collection("fights").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	leaders := []Leader{}
+	if err := cur.All(ctx, &leaders); err != nil {
+		return nil, err
+	}
+	for i := range leaders {
+		leaders[i].Rank = i + 1
+	}
+
+	return &Leaderboard{LeaderQuery: lq, ComputedAt: time.Now().UTC(), Leaders: leaders}, nil
+}
+
+// {$gte: start, $lte: end} with either side optional, nil when both are
+func dateRange(start, end *time.Time) bson.M {
+	if start == nil && end == nil {
+		return nil
+	}
+	m := bson.M{}
+	if start != nil {
+		m["$gte"] = *start
+	}
+	if end != nil {
+		m["$lte"] = *end
+	}
+	return m
+}
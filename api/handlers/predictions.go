@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/api/pkg"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/anthonybliss1/ufc-api/scrape/features"
+	"github.com/anthonybliss1/ufc-api/scrape/predict"
+	"github.com/anthonybliss1/ufc-api/scrape/ratings"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// the win probability model trained by 'scrape train', nil when no model file was found
+var winModel *predict.Model
+
+// load the model file. without one the api runs as before, minus predictions. a model trained on
+// ratings of another rating model (i.e. a different finish weight) is refused since it would be fed
+// ratings on a different scale
+func LoadWinModel(ctx context.Context, path string) {
+	m, err := predict.LoadModel(path)
+	if err != nil {
+		log.Printf("[Win probability model not loaded: %v]", err)
+		return
+	}
+
+	var stored []string
+	if err := db.MongoDB.Collection("ratings").Distinct(ctx, "model", bson.M{}).Decode(&stored); err != nil {
+		log.Printf("[Win probability model not loaded: failed to read rating models: %v]", err)
+		return
+	}
+	if len(stored) > 0 && !slices.Contains(stored, m.RatingModel) {
+		log.Printf("[Win probability model not loaded: trained on %s ratings, stored ratings are %v. retrain it]", m.RatingModel, stored)
+		return
+	}
+
+	winModel = m
+	log.Printf("[✅ Win probability model loaded | trained %s | %s]", m.TrainedAt.Format("2006-01-02"), m.RatingModel)
+}
+
+// chance of one fighter winning a matchup
+type WinProbability struct {
+	FighterID   string  `json:"fighter_id"`
+	Name        string  `json:"name"`
+	Rating      float64 `json:"rating"`
+	Probability float64 `json:"probability"`
+}
+
+// response of /upcomingFights/{id}/prediction
+type Prediction struct {
+	UpcomingFightID string                 `json:"upcoming_fight_id"`
+	ModelTrainedAt  time.Time              `json:"model_trained_at"`
+	Fighters        []WinProbability       `json:"fighters"`
+	Contributions   []predict.Contribution `json:"contributions"` // in favour of the first fighter
+}
+
+// everything needed to score a page of upcoming fights: current ratings, career numbers replayed from
+// the fighters' fights (the same features.Tracker the model was trained with) and event dates
+type matchupContext struct {
+	ratings map[string]float64
+	stale   bool // some rating comes from another rating model than the one the model was trained on
+	careers *features.Tracker
+	dates   map[string]time.Time
+}
+
+func loadMatchupContext(ctx context.Context, fights []data.UpcomingFight) (*matchupContext, error) {
+	fighterIDs, eventIDs := []string{}, []string{}
+	dobs := make(map[string]*time.Time)
+	for _, f := range fights {
+		eventIDs = append(eventIDs, f.UpcomingEventID)
+		for _, p := range f.Participants {
+			fighterIDs = append(fighterIDs, p.ID)
+			dobs[p.ID] = p.DOB
+		}
+	}
+
+	mc := &matchupContext{ratings: make(map[string]float64), dates: make(map[string]time.Time)}
+
+	history, err := data.LoadFighterHistory(ctx, db.MongoDB, fighterIDs)
+	if err != nil {
+		return nil, err
+	}
+	mc.careers = features.Replay(history, dobs)
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"fighter_id": bson.M{"$in": fighterIDs}}},
+		bson.M{"$sort": bson.D{{Key: "date", Value: -1}, {Key: "fight_id", Value: -1}}},
+		bson.M{"$group": bson.M{"_id": "$fighter_id", "rating": bson.M{"$first": "$after"}, "model": bson.M{"$first": "$model"}}},
+	}
+	cur, err := db.MongoDB.Collection("ratings").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var current []struct {
+		FighterID string  `bson:"_id"`
+		Rating    float64 `bson:"rating"`
+		Model     string  `bson:"model"`
+	}
+	if err := cur.All(ctx, &current); err != nil {
+		return nil, err
+	}
+	for _, c := range current {
+		mc.ratings[c.FighterID] = c.Rating
+		if winModel != nil && c.Model != winModel.RatingModel {
+			mc.stale = true
+		}
+	}
+
+	events, err := findByID[data.UpcomingEvent](ctx, "upcomingEvents", eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	for id, e := range events {
+		mc.dates[id] = e.Date
+	}
+
+	return mc, nil
+}
+
+// the model's read on a matchup, false when it cannot be scored (no model, ratings of another rating
+// model, not two fighters)
+func (mc *matchupContext) predict(f data.UpcomingFight) ([]WinProbability, []predict.Contribution, bool) {
+	if winModel == nil || mc.stale || len(f.Participants) != 2 {
+		return nil, nil, false
+	}
+
+	on := mc.dates[f.UpcomingEventID]
+	if on.IsZero() {
+		on = time.Now()
+	}
+
+	side := func(fighter data.Fighter) predict.Side {
+		rating, ok := mc.ratings[fighter.ID]
+		if !ok {
+			rating = ratings.DefaultOptions().Initial // first UFC fight
+		}
+		return predict.NewSide(mc.careers.Before(fighter.ID, fighter.Name, on), fighter, rating)
+	}
+
+	fa, fb := f.Participants[0], f.Participants[1]
+	a, b := side(fa), side(fb)
+	p, contributions := winModel.Explain(predict.Features(a, b))
+
+	return []WinProbability{
+		{FighterID: fa.ID, Name: fa.Name, Rating: a.Rating, Probability: p},
+		{FighterID: fb.ID, Name: fb.Name, Rating: b.Rating, Probability: 1 - p},
+	}, contributions, true
+}
+
+// win_probability for each upcoming fight, nil when no model is loaded
+func upcomingFightEmbeds(ctx context.Context, fights []data.UpcomingFight) ([]map[string]any, error) {
+	if winModel == nil {
+		return nil, nil
+	}
+
+	mc, err := loadMatchupContext(ctx, fights)
+	if err != nil {
+		return nil, err
+	}
+
+	embeds := make([]map[string]any, len(fights))
+	for i, f := range fights {
+		embeds[i] = map[string]any{}
+		if probs, _, ok := mc.predict(f); ok {
+			embeds[i]["win_probability"] = probs
+		}
+	}
+	return embeds, nil
+}
+
+// the shared tail of every upcoming fight list endpoint: paginate, add win probabilities and render
+func listUpcomingFights(w http.ResponseWriter, r *http.Request, filter bson.M) {
+	if winModel != nil {
+		r = db.WithFields(r, predict.UpcomingFightFields...)
+	}
+
+	page, ok := db.List[data.UpcomingFight](w, r, "upcomingFights", filter, upcomingFightSorts, bson.D{{Key: "_id", Value: 1}})
+	if !ok {
+		return
+	}
+
+	embeds, err := upcomingFightEmbeds(r.Context(), page.Items)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+	if embeds != nil {
+		page.Embed(embeds)
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.UpcomingFights{Items: page.Items})
+}
+
+// who the model favours and why
+func GetUpcomingFightPrediction(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxUpcomingFightKey).(*data.UpcomingFight)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fight not found")
+		return
+	}
+
+	if winModel == nil {
+		render.Status(r, 503)
+		render.PlainText(w, r, "prediction model not loaded")
+		return
+	}
+
+	mc, err := loadMatchupContext(r.Context(), []data.UpcomingFight{*f})
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	if mc.stale {
+		render.Status(r, 503)
+		render.PlainText(w, r, "prediction model does not match the stored ratings")
+		return
+	}
+
+	probs, contributions, ok := mc.predict(*f)
+	if !ok {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fight cannot be predicted")
+		return
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderJSON(w, r, Prediction{
+		UpcomingFightID: f.ID,
+		ModelTrainedAt:  winModel.TrainedAt,
+		Fighters:        probs,
+		Contributions:   contributions,
+	})
+}
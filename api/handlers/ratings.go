@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/api/pkg"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ratingSorts = db.SortFields{
+	"date":  "date",
+	"after": "after",
+}
+
+// a fighter's rating after their newest rated fight
+type CurrentRating struct {
+	Rank        int       `bson:"-" json:"rank"`
+	FighterID   string    `bson:"_id" json:"fighter_id"`
+	FighterName string    `bson:"fighter_name" json:"fighter_name"`
+	Rating      float64   `bson:"rating" json:"rating"`
+	Fights      int       `bson:"fights" json:"fights"`                         // rated fights
+	Division    string    `bson:"division,omitempty" json:"division,omitempty"` // division of the newest rated fight
+	LastFight   time.Time `bson:"last_fight" json:"last_fight"`
+}
+
+// current ratings are rebuilt once per scraper load, keyed by division
+var ratingsCache = db.NewLoadCache[[]CurrentRating]()
+
+// highest rated fighters. ?division=lightweight keeps the fighters whose newest fight was in that division
+func ListRatings(w http.ResponseWriter, r *http.Request) {
+	// checked before it becomes a cache key
+	division, err := divisionFromQuery(r)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+
+	all, err := ratingsCache.Get(r.Context(), division, func(ctx context.Context) ([]CurrentRating, error) {
+		return currentRatings(ctx, division)
+	})
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	page, ok := db.SlicePage(w, r, all, currentRatingSort, currentRatingKey, compareCurrentRating, 50, 500)
+	if !ok {
+		return
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderPage(w, r, page, map[string]any{"ratings": page.Items})
+}
+
+// order of the current ratings, what their cursors are made for
+var currentRatingSort = bson.D{{Key: "rating", Value: -1}, {Key: "_id", Value: 1}}
+
+func currentRatingKey(c CurrentRating) []any {
+	return []any{c.Rating, c.FighterID}
+}
+
+func compareCurrentRating(c CurrentRating, key []any) int {
+	rating, _ := key[0].(float64)
+	id, _ := key[1].(string)
+	if c.Rating != rating {
+		return cmp.Compare(rating, c.Rating)
+	}
+	return cmp.Compare(c.FighterID, id)
+}
+
+func currentRatings(ctx context.Context, division string) ([]CurrentRating, error) {
+	pipeline := bson.A{
+		bson.M{"$sort": bson.D{{Key: "date", Value: -1}, {Key: "fight_id", Value: -1}}},
+		bson.M{"$group": bson.M{
+			"_id":          "$fighter_id",
+			"fighter_name": bson.M{"$first": "$fighter_name"},
+			"rating":       bson.M{"$first": "$after"},
+			"fights":       bson.M{"$first": "$fights"},
+			"division":     bson.M{"$first": "$division"},
+			"last_fight":   bson.M{"$first": "$date"},
+		}},
+	}
+	if division != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"division": division}})
+	}
+	pipeline = append(pipeline, bson.M{"$sort": currentRatingSort})
+
+	cur, err := db.MongoDB.Collection("ratings").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := []CurrentRating{}
+	if err := cur.All(ctx, &ratings); err != nil {
+		return nil, err
+	}
+	for i := range ratings {
+		ratings[i].Rank = i + 1
+	}
+	return ratings, nil
+}
+
+// the fighter's rating after each of their fights, oldest first. ?division= keeps the fights in that division
+func GetFighterRatings(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter not found")
+		return
+	}
+
+	division, err := divisionFromQuery(r)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+
+	filter := bson.M{"fighter_id": f.ID}
+	if division != "" {
+		filter["division"] = division
+	}
+
+	page, ok := db.List[data.RatingSnapshot](w, r, "ratings", filter, ratingSorts, bson.D{{Key: "date", Value: 1}})
+	if !ok {
+		return
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderPage(w, r, page, data.RatingSnapshots{Items: page.Items})
+}
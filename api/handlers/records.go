@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// the record lists the scraper keeps, with their leader
+func ListRecords(w http.ResponseWriter, r *http.Request) {
+	cur, err := db.MongoDB.Collection("records").Find(r.Context(), bson.M{})
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+	lists := []data.RecordList{}
+	if err := cur.All(r.Context(), &lists); err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	// in the order of data.RecordTypes, only the first place
+	slices.SortFunc(lists, func(a, b data.RecordList) int {
+		return slices.Index(data.RecordTypes, a.Type) - slices.Index(data.RecordTypes, b.Type)
+	})
+	for i := range lists {
+		if len(lists[i].Entries) > 1 {
+			lists[i].Entries = lists[i].Entries[:1]
+		}
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderPage(w, r, &db.Page[data.RecordList]{Items: lists}, map[string]any{"records": lists})
+}
+
+// one record list: /records/longest-win-streak?limit=25
+func GetRecord(w http.ResponseWriter, r *http.Request) {
+	recordType := chi.URLParam(r, "type")
+	if !slices.Contains(data.RecordTypes, recordType) {
+		render.Status(r, 404)
+		render.PlainText(w, r, "record not found")
+		return
+	}
+
+	var list data.RecordList
+	err := db.MongoDB.Collection("records").FindOne(r.Context(), bson.M{"_id": recordType}).Decode(&list)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+	list.Type = recordType // not computed yet: an empty list
+
+	if limit := int(db.LimitFromQuery(r, 10, 100)); len(list.Entries) > limit {
+		list.Entries = list.Entries[:limit]
+	}
+	if list.Entries == nil {
+		list.Entries = []data.RecordEntry{}
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderFields(w, r, list, nil)
+}
+
+// the fighter's streaks for /fighters/{id}, nil when they have no fights
+func fighterStreaks(ctx context.Context, fighterID string) (*data.Streaks, error) {
+	var rec data.FighterRecord
+	err := db.MongoDB.Collection("fighterRecords").FindOne(ctx, bson.M{"_id": fighterID}).Decode(&rec)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rec.Streaks, nil
+}
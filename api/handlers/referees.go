@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fight_detail of championship bouts, i.e. "UFC Lightweight Title Bout"
+const titleFightRegex = "title"
+
+// spellings that normalizing does not collapse, normalized variant -> normalized name
+var refereeAliases = map[string]string{
+	"big john mccarthy":  "john mccarthy",
+	"daniel miragliotta": "dan miragliotta",
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// "Herb  Dean", "herb dean" and "Herb Dean." all become "herb dean"
+func normalizeReferee(name string) string {
+	name = accents.Replace(strings.ToLower(name))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			b.WriteRune(' ')
+		}
+	}
+
+	name = strings.Join(strings.Fields(b.String()), " ")
+	if alias, ok := refereeAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// fights a referee worked that ended by one method
+type MethodCount struct {
+	Method string  `bson:"method" json:"method"`
+	Fights int     `bson:"fights" json:"fights"`
+	Rate   float64 `bson:"-" json:"rate"` // share of the referee's fights
+}
+
+// a referee and the fights they worked
+type Referee struct {
+	ID                 string        `json:"id"`                 // normalized name, used by /referees/{name}
+	Name               string        `json:"name"`               // most used spelling
+	Variants           []string      `json:"variants,omitempty"` // every spelling found in the fights
+	Fights             int           `json:"fights"`
+	TitleFights        int           `json:"title_fights"`
+	Finishes           int           `json:"finishes"`
+	FinishRate         float64       `json:"finish_rate"`
+	Methods            []MethodCount `json:"methods"`              // finish (and decision) rates by method
+	AvgStoppageRound   float64       `json:"avg_stoppage_round"`   // over finishes
+	AvgStoppageSeconds float64       `json:"avg_stoppage_seconds"` // time into the fight, over finishes
+
+	roundSum   int
+	secondsSum int
+	spellings  map[string]int
+}
+
+// per spelling totals as they come out of the aggregation
+type refereeRow struct {
+	Referee     string        `bson:"_id"`
+	Fights      int           `bson:"fights"`
+	TitleFights int           `bson:"title_fights"`
+	Finishes    int           `bson:"finishes"`
+	RoundSum    int           `bson:"round_sum"`
+	SecondsSum  int           `bson:"seconds_sum"`
+	Methods     []MethodCount `bson:"methods"`
+}
+
+// referees are rebuilt once per scraper load
+var refereesCache = db.NewLoadCache[[]*Referee]()
+
+// every referee, most fights first. ?q= filters by name
+func ListReferees(w http.ResponseWriter, r *http.Request) {
+	refs, err := refereesCache.Get(r.Context(), "all", computeReferees)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	items := make([]*Referee, 0, len(refs))
+	q := normalizeReferee(r.URL.Query().Get("q"))
+	for _, ref := range refs {
+		if strings.Contains(ref.ID, q) {
+			items = append(items, ref)
+		}
+	}
+
+	page, ok := db.SlicePage(w, r, items, refereeSort, refereeKey, compareReferee, 50, 500)
+	if !ok {
+		return
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderPage(w, r, page, map[string]any{"referees": page.Items})
+}
+
+// order of the referee list, what its cursors are made for
+var refereeSort = bson.D{{Key: "fights", Value: -1}, {Key: "_id", Value: 1}}
+
+func refereeKey(ref *Referee) []any {
+	return []any{int64(ref.Fights), ref.ID}
+}
+
+func compareReferee(ref *Referee, key []any) int {
+	fights, _ := key[0].(int64)
+	id, _ := key[1].(string)
+	if int64(ref.Fights) != fights {
+		return cmp.Compare(fights, int64(ref.Fights))
+	}
+	return cmp.Compare(ref.ID, id)
+}
+
+// one referee by any spelling of their name
+func GetReferee(w http.ResponseWriter, r *http.Request) {
+	refs, err := refereesCache.Get(r.Context(), "all", computeReferees)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	id := normalizeReferee(chi.URLParam(r, "name"))
+	for _, ref := range refs {
+		if ref.ID == id {
+			db.CacheFor(w, 5*time.Minute)
+			db.RenderJSON(w, r, ref)
+			return
+		}
+	}
+
+	render.Status(r, 404)
+	render.PlainText(w, r, "referee not found")
+}
+
+// totals per referee spelling from the fights collection, merged by normalized name
+func computeReferees(ctx context.Context) ([]*Referee, error) {
+	finish := isFinishExpr()
+	title := bson.M{"$regexMatch": bson.M{"input": bson.M{"$ifNull": bson.A{"$fight_detail", ""}}, "regex": titleFightRegex, "options": "i"}}
+	ifFinish := func(v any) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{finish, v, 0}}}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"referee": bson.M{"$nin": bson.A{nil, ""}}}},
+		bson.M{"$group": bson.M{
+			"_id":          bson.M{"referee": "$referee", "method": "$method"},
+			"fights":       bson.M{"$sum": 1},
+			"title_fights": bson.M{"$sum": bson.M{"$cond": bson.A{title, 1, 0}}},
+			"finishes":     ifFinish(1),
+			"round_sum":    ifFinish("$round"),
+			"seconds_sum":  ifFinish(fightSecondsExpr()),
+		}},
+		bson.M{"$group": bson.M{
+			"_id":          "$_id.referee",
+			"fights":       bson.M{"$sum": "$fights"},
+			"title_fights": bson.M{"$sum": "$title_fights"},
+			"finishes":     bson.M{"$sum": "$finishes"},
+			"round_sum":    bson.M{"$sum": "$round_sum"},
+			"seconds_sum":  bson.M{"$sum": "$seconds_sum"},
+			"methods":      bson.M{"$push": bson.M{"method": "$_id.method", "fights": "$fights"}},
+		}},
+	}
+
+	cur, err := db.MongoDB.Collection("fights").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []refereeRow
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*Referee)
+	for _, row := range rows {
+		id := normalizeReferee(row.Referee)
+		if id == "" {
+			continue
+		}
+
+		ref, ok := byID[id]
+		if !ok {
+			ref = &Referee{ID: id, spellings: map[string]int{}}
+			byID[id] = ref
+		}
+
+		ref.spellings[strings.TrimSpace(row.Referee)] += row.Fights
+		ref.Fights += row.Fights
+		ref.TitleFights += row.TitleFights
+		ref.Finishes += row.Finishes
+		ref.roundSum += row.RoundSum
+		ref.secondsSum += row.SecondsSum
+
+		for _, m := range row.Methods {
+			i := slices.IndexFunc(ref.Methods, func(x MethodCount) bool { return x.Method == m.Method })
+			if i < 0 {
+				ref.Methods = append(ref.Methods, MethodCount{Method: m.Method})
+				i = len(ref.Methods) - 1
+			}
+			ref.Methods[i].Fights += m.Fights
+		}
+	}
+
+	refs := make([]*Referee, 0, len(byID))
+	for _, ref := range byID {
+		ref.finish()
+		refs = append(refs, ref)
+	}
+	slices.SortFunc(refs, func(a, b *Referee) int {
+		if a.Fights != b.Fights {
+			return cmp.Compare(b.Fights, a.Fights)
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return refs, nil
+}
+
+// rates, averages and the display name once every spelling has been merged
+func (ref *Referee) finish() {
+	for s := range ref.spellings {
+		ref.Variants = append(ref.Variants, s)
+	}
+	slices.SortFunc(ref.Variants, func(a, b string) int {
+		if ref.spellings[a] != ref.spellings[b] {
+			return cmp.Compare(ref.spellings[b], ref.spellings[a])
+		}
+		return cmp.Compare(a, b)
+	})
+	ref.Name = ref.Variants[0]
+	if len(ref.Variants) == 1 {
+		ref.Variants = nil
+	}
+
+	if ref.Fights > 0 {
+		ref.FinishRate = float64(ref.Finishes) / float64(ref.Fights)
+		for i := range ref.Methods {
+			ref.Methods[i].Rate = float64(ref.Methods[i].Fights) / float64(ref.Fights)
+		}
+	}
+	slices.SortFunc(ref.Methods, func(a, b MethodCount) int {
+		if a.Fights != b.Fights {
+			return cmp.Compare(b.Fights, a.Fights)
+		}
+		return cmp.Compare(a.Method, b.Method)
+	})
+
+	if ref.Finishes > 0 {
+		ref.AvgStoppageRound = float64(ref.roundSum) / float64(ref.Finishes)
+		ref.AvgStoppageSeconds = float64(ref.secondsSum) / float64(ref.Finishes)
+	}
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/api/pkg"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// true for the fights that ended before the judges (KO/TKO, TKO - Doctor's Stoppage, Submission), the same
+// fights as data.IsFinish
+func isFinishExpr() bson.M {
+	return bson.M{"$in": bson.A{"$method_category", data.FinishCategories}}
+}
+
+// the round lengths in minutes of a time_format like "3 Rnd (5-5-5)" or "1 Rnd + 2OT (15-3-3)"
+const roundLengthsRegex = `\(\s*(\d+(?:\s*-\s*\d+)*)\s*\)`
+
+// landed / attempted of one strike type
+type StrikeCount struct {
+	Landed    int `bson:"landed" json:"landed"`
+	Attempted int `bson:"attempted" json:"attempted"`
+}
+
+// significant strikes split by where they landed and where they were thrown from
+type StrikeDistribution struct {
+	Head     StrikeCount `bson:"head" json:"head"`
+	Body     StrikeCount `bson:"body" json:"body"`
+	Leg      StrikeCount `bson:"leg" json:"leg"`
+	Distance StrikeCount `bson:"distance" json:"distance"`
+	Clinch   StrikeCount `bson:"clinch" json:"clinch"`
+	Ground   StrikeCount `bson:"ground" json:"ground"`
+}
+
+// a fighter's numbers over a set of fights
+type StatsBlock struct {
+	Fights          int                `bson:"fights" json:"fights"`
+	Wins            int                `bson:"wins" json:"wins"`
+	Losses          int                `bson:"losses" json:"losses"`
+	Draws           int                `bson:"draws" json:"draws"`
+	Finishes        int                `bson:"finishes" json:"finishes"`               // wins by KO/TKO or submission
+	FinishRate      float64            `bson:"-" json:"finish_rate"`                   // finishes / wins
+	Knockdowns      int                `bson:"knockdowns" json:"knockdowns"`           // knockdowns scored
+	ControlSeconds  int                `bson:"control_seconds" json:"control_seconds"` // total control time
+	FightSeconds    int                `bson:"fight_seconds" json:"fight_seconds"`     // total time in the cage
+	AvgFightSeconds float64            `bson:"-" json:"avg_fight_seconds"`             // fight_seconds / fights
+	SigStr          StrikeCount        `bson:"sig_str" json:"sig_str"`                 // significant strikes
+	Strikes         StrikeDistribution `bson:"strikes" json:"strike_distribution"`     // significant strikes by target and position
+	TD              StrikeCount        `bson:"td" json:"takedowns"`                    // takedowns
+	SubAttempts     int                `bson:"sub_attempts" json:"sub_attempts"`       // submission attempts
+}
+
+func (s *StatsBlock) derive() {
+	if s.Wins > 0 {
+		s.FinishRate = float64(s.Finishes) / float64(s.Wins)
+	}
+	if s.Fights > 0 {
+		s.AvgFightSeconds = float64(s.FightSeconds) / float64(s.Fights)
+	}
+}
+
+// response of /fighters/{id}/stats
+type FighterStats struct {
+	FighterID string                `json:"fighter_id"`
+	Since     *time.Time            `json:"since,omitempty"` // ?since= window
+	Last      int                   `json:"last,omitempty"`  // ?last= window
+	Overall   StatsBlock            `json:"overall"`
+	ByOutcome map[string]StatsBlock `json:"by_outcome"` // W, L, D (and NC when recorded)
+}
+
+// career stats worked out from the fighter's fights. ?since=2020-01-01 and ?last=5 narrow the window
+func GetFighterStats(w http.ResponseWriter, r *http.Request) {
+	f, _ := r.Context().Value(pkg.CtxFighterKey).(*data.Fighter)
+	if f == nil {
+		render.Status(r, 404)
+		render.PlainText(w, r, "fighter not found")
+		return
+	}
+
+	stats := FighterStats{FighterID: f.ID, ByOutcome: map[string]StatsBlock{}}
+	q := r.URL.Query()
+
+	pipeline := bson.A{bson.M{"$match": bson.M{"participants.fighter_id": f.ID}}}
+	pipeline = append(pipeline, withEventDate()...)
+
+	if v := q.Get("since"); v != "" {
+		since, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("since must be YYYY-MM-DD: %v", err)))
+			return
+		}
+		stats.Since = &since
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"date": bson.M{"$gte": since}}})
+	}
+
+	pipeline = append(pipeline, bson.M{"$sort": bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: 1}}})
+
+	if v := q.Get("last"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("last must be a positive number")))
+			return
+		}
+		stats.Last = n
+		pipeline = append(pipeline, bson.M{"$limit": n})
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$set": bson.M{"me": participantOf(f.ID)}},
+		bson.M{"$facet": bson.M{
+			"overall":    bson.A{bson.M{"$group": statsGroup(nil)}, bson.M{"$project": statsShape()}},
+			"by_outcome": bson.A{bson.M{"$group": statsGroup("$me.outcome")}, bson.M{"$project": statsShape()}},
+		}},
+	)
+
+	cur, err := db.MongoDB.Collection("fights").Aggregate(r.Context(), pipeline)
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+	defer cur.Close(r.Context())
+
+	var out []struct {
+		Overall   []StatsBlock `bson:"overall"`
+		ByOutcome []struct {
+			StatsBlock `bson:",inline"`
+			Outcome    string `bson:"_id"`
+		} `bson:"by_outcome"`
+	}
+	if err := cur.All(r.Context(), &out); err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "decode error")
+		return
+	}
+
+	if len(out) > 0 {
+		if len(out[0].Overall) > 0 {
+			stats.Overall = out[0].Overall[0]
+			stats.Overall.derive()
+		}
+		for _, b := range out[0].ByOutcome {
+			b.StatsBlock.derive()
+			stats.ByOutcome[b.Outcome] = b.StatsBlock
+		}
+	}
+
+	db.CacheFor(w, 30*time.Second)
+
+	db.RenderJSON(w, r, stats)
+}
+
+// stages that add the date of the fight's event as 'date'
+func withEventDate() bson.A {
+	return bson.A{
+		bson.M{"$lookup": bson.M{"from": "events", "localField": "event_id", "foreignField": "_id", "as": "event"}},
+		bson.M{"$set": bson.M{"date": bson.M{"$first": "$event.date"}}},
+		bson.M{"$unset": "event"},
+	}
+}
+
+// the participants entry of the given fighter
+func participantOf(fighterID string) bson.M {
+	return bson.M{"$first": bson.M{"$filter": bson.M{
+		"input": "$participants",
+		"cond":  bson.M{"$eq": bson.A{"$$this.fighter_id", fighterID}},
+	}}}
+}
+
+// seconds in a "M:SS" string field, 0 for "--" or anything else that does not parse
+func clockSecondsExpr(field string) bson.M {
+	toInt := func(i int) bson.M {
+		return bson.M{"$convert": bson.M{
+			"input":   bson.M{"$arrayElemAt": bson.A{"$$parts", i}},
+			"to":      "int",
+			"onError": 0,
+			"onNull":  0,
+		}}
+	}
+
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"parts": bson.M{"$split": bson.A{bson.M{"$ifNull": bson.A{field, ""}}, ":"}}},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$size": "$$parts"}, 2}},
+			bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{toInt(0), 60}}, toInt(1)}},
+			0,
+		}},
+	}}
+}
+
+// seconds the fight lasted, the pipeline version of data.ElapsedSeconds: the rounds before the last one
+// with the lengths of the time_format (the last length repeats, data.DefaultRoundSeconds when none are
+// listed) plus the end time. 0 when the end time does not parse
+func fightSecondsExpr() bson.M {
+	match := bson.M{"$regexFind": bson.M{"input": bson.M{"$ifNull": bson.A{"$time_format", ""}}, "regex": roundLengthsRegex}}
+	lengths := bson.M{"$let": bson.M{
+		"vars": bson.M{"m": match},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$$m", nil}},
+			bson.A{data.DefaultRoundSeconds},
+			bson.M{"$map": bson.M{
+				"input": bson.M{"$split": bson.A{bson.M{"$arrayElemAt": bson.A{"$$m.captures", 0}}, "-"}},
+				"as":    "mins",
+				"in":    bson.M{"$multiply": bson.A{bson.M{"$toInt": bson.M{"$trim": bson.M{"input": "$$mins"}}}, 60}},
+			}},
+		}},
+	}}
+
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{
+			"clock":   clockSecondsExpr("$end_time"),
+			"before":  bson.M{"$max": bson.A{bson.M{"$subtract": bson.A{bson.M{"$ifNull": bson.A{"$round", 0}}, 1}}, 0}},
+			"lengths": lengths,
+		},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$or": bson.A{bson.M{"$eq": bson.A{"$$clock", 0}}, bson.M{"$lt": bson.A{"$round", 1}}}},
+			0,
+			bson.M{"$add": bson.A{"$$clock", bson.M{"$reduce": bson.M{
+				"input":        bson.M{"$range": bson.A{0, "$$before"}},
+				"initialValue": 0,
+				"in": bson.M{"$add": bson.A{"$$value", bson.M{"$arrayElemAt": bson.A{
+					"$$lengths", bson.M{"$min": bson.A{"$$this", bson.M{"$subtract": bson.A{bson.M{"$size": "$$lengths"}, 1}}}},
+				}}}},
+			}}}},
+		}},
+	}}
+}
+
+// $group summing the 'me' participant of each fight, flat. statsShape nests it into a StatsBlock
+func statsGroup(id any) bson.M {
+	count := func(cond bson.M) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, 1, 0}}}
+	}
+
+	won := bson.M{"$eq": bson.A{"$me.outcome", "W"}}
+
+	group := bson.M{
+		"_id":    id,
+		"fights": bson.M{"$sum": 1},
+		"wins":   count(won),
+		"losses": count(bson.M{"$eq": bson.A{"$me.outcome", "L"}}),
+		"draws":  count(bson.M{"$eq": bson.A{"$me.outcome", "D"}}),
+		"finishes": count(bson.M{"$and": bson.A{
+			won,
+			isFinishExpr(),
+		}}),
+		"knockdowns":      bson.M{"$sum": "$me.kd"},
+		"sub_attempts":    bson.M{"$sum": "$me.sub"},
+		"control_seconds": bson.M{"$sum": clockSecondsExpr("$me.ctrl")},
+		"fight_seconds":   bson.M{"$sum": fightSecondsExpr()},
+	}
+	for _, prefix := range strikePrefixes {
+		group[prefix+"_landed"] = bson.M{"$sum": "$me." + prefix + "_landed"}
+		group[prefix+"_attempted"] = bson.M{"$sum": "$me." + prefix + "_attempted"}
+	}
+
+	return group
+}
+
+// FightStats fields that come as _landed / _attempted pairs
+var strikePrefixes = []string{"sig_str", "td", "head", "body", "leg", "distance", "clinch", "ground"}
+
+// $project nesting the flat statsGroup output into the shape of StatsBlock
+func statsShape() bson.M {
+	pair := func(prefix string) bson.M {
+		return bson.M{"landed": "$" + prefix + "_landed", "attempted": "$" + prefix + "_attempted"}
+	}
+
+	return bson.M{
+		"_id":             1,
+		"fights":          1,
+		"wins":            1,
+		"losses":          1,
+		"draws":           1,
+		"finishes":        1,
+		"knockdowns":      1,
+		"sub_attempts":    1,
+		"control_seconds": 1,
+		"fight_seconds":   1,
+		"sig_str":         pair("sig_str"),
+		"td":              pair("td"),
+		"strikes": bson.M{
+			"head":     pair("head"),
+			"body":     pair("body"),
+			"leg":      pair("leg"),
+			"distance": pair("distance"),
+			"clinch":   pair("clinch"),
+			"ground":   pair("ground"),
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// how often a technique finished a fight
+type TechniqueCount struct {
+	Technique      string  `bson:"technique" json:"technique"`
+	MethodCategory string  `bson:"method_category" json:"method_category"`
+	Fights         int     `bson:"fights" json:"fights"`
+	Share          float64 `bson:"-" json:"share"` // of the listed stoppages
+}
+
+// counts only change with a scraper load, keyed by the filters (both checked, so the keys are bounded)
+var techniquesCache = db.NewLoadCache[[]TechniqueCount]()
+
+// ?method_category= checked against the taxonomy, empty when not given
+func methodCategoryFromQuery(r *http.Request) (string, error) {
+	v := r.URL.Query().Get("method_category")
+	if v != "" && !slices.Contains(data.MethodCategories, v) {
+		return "", fmt.Errorf("unknown method_category %q", v)
+	}
+	return v, nil
+}
+
+// ?division= checked against the division slugs, empty when not given
+func divisionFromQuery(r *http.Request) (string, error) {
+	v := r.URL.Query().Get("division")
+	if v != "" {
+		if _, ok := data.DivisionBySlug(v); !ok {
+			return "", fmt.Errorf("unknown division %q", v)
+		}
+	}
+	return v, nil
+}
+
+// finishing techniques by how many fights they ended. ?method_category=SUBMISSION and ?division= narrow it
+func ListTechniques(w http.ResponseWriter, r *http.Request) {
+	category, err := methodCategoryFromQuery(r)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+	division, err := divisionFromQuery(r)
+	if err != nil {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+		return
+	}
+
+	match := bson.M{"technique": bson.M{"$exists": true, "$ne": ""}}
+	if category != "" {
+		match["method_category"] = category
+	}
+	if division != "" {
+		match["division"] = division
+	}
+
+	techniques, err := techniquesCache.Get(r.Context(), category+"|"+division, func(ctx context.Context) ([]TechniqueCount, error) {
+		return countTechniques(ctx, match)
+	})
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderPage(w, r, &db.Page[TechniqueCount]{Items: techniques}, map[string]any{"techniques": techniques})
+}
+
+func countTechniques(ctx context.Context, match bson.M) ([]TechniqueCount, error) {
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":    bson.M{"technique": "$technique", "method_category": "$method_category"},
+			"fights": bson.M{"$sum": 1},
+		}},
+		bson.M{"$project": bson.M{
+			"_id":             0,
+			"technique":       "$_id.technique",
+			"method_category": "$_id.method_category",
+			"fights":          1,
+		}},
+		bson.M{"$sort": bson.D{{Key: "fights", Value: -1}, {Key: "technique", Value: 1}}},
+	}
+
+	cur, err := db.MongoDB.Collection("fights").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	techniques := []TechniqueCount{}
+	if err := cur.All(ctx, &techniques); err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, t := range techniques {
+		total += t.Fights
+	}
+	for i := range techniques {
+		techniques[i].Share = float64(techniques[i].Fights) / float64(total)
+	}
+	return techniques, nil
+}
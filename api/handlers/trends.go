@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
+	"github.com/anthonybliss1/ufc-api/api/db"
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// how long a trend aggregation may run
+const trendsTimeout = 20 * time.Second
+
+// trend metrics, each worked out from the totals of a period
+var trendMetrics = map[string]func(trendTotals) *float64{
+	"finish_rate": func(t trendTotals) *float64 { return share(t.Finishes, t.Fights) },
+	// per fighter, over the fights that have strike stats
+	"sig_str_per_min": func(t trendTotals) *float64 {
+		if t.StatsSeconds == 0 {
+			return nil
+		}
+		v := float64(t.SigStrLanded) / 2 / (float64(t.StatsSeconds) / 60)
+		return &v
+	},
+	// both fighters together
+	"td_attempts_per_fight": func(t trendTotals) *float64 { return share(t.TDAttempted, t.StatsFights) },
+	"decision_share":        func(t trendTotals) *float64 { return share(t.Decisions, t.Fights) },
+}
+
+// periods a trend can be grouped by, as the expression of the period's first year
+var trendGroups = map[string]any{
+	"year":   bson.M{"$year": "$date"},
+	"decade": bson.M{"$subtract": bson.A{bson.M{"$year": "$date"}, bson.M{"$mod": bson.A{bson.M{"$year": "$date"}, 10}}}},
+}
+
+func share(num, den int) *float64 {
+	if den == 0 {
+		return nil
+	}
+	v := float64(num) / float64(den)
+	return &v
+}
+
+// fight totals of one period, shared by every metric
+type trendTotals struct {
+	Period       int `bson:"_id"`
+	Fights       int `bson:"fights"`
+	Finishes     int `bson:"finishes"`
+	Decisions    int `bson:"decisions"`
+	StatsFights  int `bson:"stats_fights"`  // fights with strike stats, old events have none
+	StatsSeconds int `bson:"stats_seconds"` // time fought in them
+	SigStrLanded int `bson:"sig_str_landed"`
+	TDAttempted  int `bson:"td_attempted"`
+}
+
+// one point of a trend
+type TrendPoint struct {
+	Period int      `json:"period"` // year, or the first year of the decade
+	Fights int      `json:"fights"`
+	Value  *float64 `json:"value"` // null when the period has nothing to measure
+}
+
+// response of /trends
+type Trend struct {
+	Metric     string       `json:"metric"`
+	GroupBy    string       `json:"group_by"`
+	Division   string       `json:"division,omitempty"`
+	ComputedAt time.Time    `json:"computed_at"`
+	Points     []TrendPoint `json:"points"`
+}
+
+// the totals of every period, with the time they were aggregated
+type trendSeries struct {
+	ComputedAt time.Time
+	Totals     []trendTotals
+}
+
+// totals are computed once per scraper load for every grouping and division, the metrics are cheap
+var trendsCache = db.NewLoadCache[*trendSeries]()
+
+// /trends?metric=finish_rate&group_by=year&division=lightweight, oldest period first
+func GetTrends(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	trend := Trend{Metric: q.Get("metric"), GroupBy: q.Get("group_by"), Division: q.Get("division")}
+
+	if trend.GroupBy == "" {
+		trend.GroupBy = "year"
+	}
+	metric, ok := trendMetrics[trend.Metric]
+	if !ok {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("metric must be one of %v, got %q", trendMetricNames(), trend.Metric)))
+		return
+	}
+	if _, ok := trendGroups[trend.GroupBy]; !ok {
+		render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("group_by must be year or decade, got %q", trend.GroupBy)))
+		return
+	}
+	if trend.Division != "" {
+		if _, ok := data.DivisionBySlug(trend.Division); !ok {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(fmt.Errorf("unknown division %q", trend.Division)))
+			return
+		}
+	}
+
+	series, err := trendsCache.Get(r.Context(), trend.GroupBy+"|"+trend.Division, func(ctx context.Context) (*trendSeries, error) {
+		return computeTrendTotals(ctx, trend.GroupBy, trend.Division)
+	})
+	if err != nil {
+		render.Status(r, 500)
+		render.PlainText(w, r, "db error")
+		return
+	}
+
+	trend.ComputedAt = series.ComputedAt
+	trend.Points = make([]TrendPoint, len(series.Totals))
+	for i, t := range series.Totals {
+		trend.Points[i] = TrendPoint{Period: t.Period, Fights: t.Fights, Value: metric(t)}
+	}
+
+	db.CacheFor(w, 5*time.Minute)
+
+	db.RenderJSON(w, r, trend)
+}
+
+func trendMetricNames() []string {
+	names := make([]string, 0, len(trendMetrics))
+	for name := range trendMetrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func computeTrendTotals(ctx context.Context, groupBy, division string) (*trendSeries, error) {
+	ctx, cancel := context.WithTimeout(ctx, trendsTimeout)
+	defer cancel()
+
+	count := func(cond any) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, 1, 0}}}
+	}
+	hasStats := bson.M{"$gt": bson.A{bson.M{"$sum": "$participants.sig_str_attempted"}, 0}}
+
+	pipeline := bson.A{}
+	if division != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"division": division}})
+	}
+	pipeline = append(pipeline, withEventDate()...)
+	pipeline = append(pipeline,
+		bson.M{"$match": bson.M{"date": bson.M{"$type": "date"}}},
+		bson.M{"$group": bson.M{
+			"_id":            trendGroups[groupBy],
+			"fights":         bson.M{"$sum": 1},
+			"finishes":       count(isFinishExpr()),
+			"decisions":      count(bson.M{"$in": bson.A{"$method_category", data.DecisionCategories}}),
+			"stats_fights":   count(hasStats),
+			"stats_seconds":  bson.M{"$sum": bson.M{"$cond": bson.A{hasStats, fightSecondsExpr(), 0}}},
+			"sig_str_landed": bson.M{"$sum": bson.M{"$sum": "$participants.sig_str_landed"}},
+			"td_attempted":   bson.M{"$sum": bson.M{"$sum": "$participants.td_attempted"}},
+		}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+	)
+
+	cur, err := db.MongoDB.Collection("fights").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &trendSeries{ComputedAt: time.Now().UTC(), Totals: []trendTotals{}}
+	if err := cur.All(ctx, &series.Totals); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"log"
@@ -44,6 +45,13 @@ func init() {
 func main() {
 	db.InitMongo()
 
+	// trained with 'scrape train', predictions are left out when the file is missing
+	modelPath := os.Getenv("MODEL_PATH")
+	if modelPath == "" {
+		modelPath = "model.json"
+	}
+	handlers.LoadWinModel(context.Background(), modelPath)
+
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.RealIP)
@@ -58,19 +66,29 @@ func main() {
 		r.Get("/search", handlers.SearchFights) // GET /fights/search
 
 		r.Route("/{fightID}", func(r chi.Router) {
-			r.Use(pkg.FightCtx)           // Load the *Fight on the request context
-			r.Get("/", handlers.GetFight) // GET /fights/123
+			r.With(pkg.ProjectFields, pkg.FightCtx).Get("/", handlers.GetFight) // GET /fights/123
 		})
 	})
 
 	// defining /fighters route with subroute for /fighters/{id} and /search
 	r.Route("/fighters", func(r chi.Router) {
 		r.Get("/", handlers.ListFighters)
-		r.Get("/search", handlers.SearchFighters) // GET /fights/search
+		r.Get("/search", handlers.SearchFighters)   // GET /fights/search
+		r.Get("/compare", handlers.CompareFighters) // GET /fighters/compare?ids=123,456,789
 
 		r.Route("/{fighterID}", func(r chi.Router) {
-			r.Use(pkg.FighterCtx)           // Load the *Fighter on the request context
-			r.Get("/", handlers.GetFighter) // GET /fighters/123
+			r.With(pkg.ProjectFields, pkg.FighterCtx).Get("/", handlers.GetFighter) // GET /fighters/123 (?as_of=2022-01-01 for a past version)
+
+			r.Group(func(r chi.Router) {
+				r.Use(pkg.FighterCtx)                              // Load the whole *Fighter on the request context
+				r.Get("/history", handlers.GetFighterHistory)      // GET /fighters/123/history
+				r.Get("/fights", handlers.ListFighterFights)       // GET /fighters/123/fights
+				r.Get("/opponents", handlers.ListFighterOpponents) // GET /fighters/123/opponents
+				r.Get("/upcoming", handlers.ListFighterUpcoming)   // GET /fighters/123/upcoming
+				r.Get("/vs/{opponentID}", handlers.GetHeadToHead)  // GET /fighters/123/vs/456
+				r.Get("/stats", handlers.GetFighterStats)          // GET /fighters/123/stats (?since=2020-01-01, ?last=5)
+				r.Get("/ratings", handlers.GetFighterRatings)      // GET /fighters/123/ratings (?division=lightweight)
+			})
 		})
 	})
 
@@ -80,8 +98,8 @@ func main() {
 		r.Get("/search", handlers.SearchEvents) // GET /events/search
 
 		r.Route("/{eventID}", func(r chi.Router) {
-			r.Use(pkg.EventCtx)           // Load the *Event on the request context
-			r.Get("/", handlers.GetEvent) // GET /events/123
+			r.With(pkg.ProjectFields, pkg.EventCtx).Get("/", handlers.GetEvent) // GET /events/123
+			r.With(pkg.EventCtx).Get("/fights", handlers.ListEventFights)       // GET /events/123/fights
 		})
 	})
 
@@ -91,8 +109,8 @@ func main() {
 		r.Get("/search", handlers.SearchUpcomingEvents) // GET /upcomingEvents/search
 
 		r.Route("/{upcomingEventID}", func(r chi.Router) {
-			r.Use(pkg.UpcomingEventCtx)           // Load the *UpcomingEvent on the request context
-			r.Get("/", handlers.GetUpcomingEvent) // GET /upcomingEvents/123
+			r.With(pkg.ProjectFields, pkg.UpcomingEventCtx).Get("/", handlers.GetUpcomingEvent) // GET /upcomingEvents/123
+			r.With(pkg.UpcomingEventCtx).Get("/fights", handlers.ListUpcomingEventFights)       // GET /upcomingEvents/123/fights
 		})
 	})
 
@@ -101,11 +119,36 @@ func main() {
 		r.Get("/", handlers.ListUpcomingFights)
 
 		r.Route("/{upcomingFightID}", func(r chi.Router) {
-			r.Use(pkg.UpcomingFightCtx)           // Load the *UpcomingFight on the request context
-			r.Get("/", handlers.GetUpcomingFight) // GET /upcomingFights/123
+			r.With(pkg.ProjectFields, pkg.UpcomingFightCtx).Get("/", handlers.GetUpcomingFight)  // GET /upcomingFights/123
+			r.With(pkg.UpcomingFightCtx).Get("/prediction", handlers.GetUpcomingFightPrediction) // GET /upcomingFights/123/prediction
 		})
 	})
 
+	// defining /divisions route, divisions are parsed from the fight details
+	r.Route("/divisions", func(r chi.Router) {
+		r.Get("/", handlers.ListDivisions)
+		r.Get("/{slug}/fighters", handlers.ListDivisionFighters) // GET /divisions/lightweight/fighters (?by=primary)
+		r.Get("/{slug}/fights", handlers.ListDivisionFights)     // GET /divisions/lightweight/fights
+	})
+
+	// defining /records route, the lists are rebuilt by the scraper after every load
+	r.Route("/records", func(r chi.Router) {
+		r.Get("/", handlers.ListRecords)     // GET /records
+		r.Get("/{type}", handlers.GetRecord) // GET /records/longest-win-streak?limit=25
+	})
+
+	r.Get("/techniques", handlers.ListTechniques)    // GET /techniques?method_category=SUBMISSION
+	r.Get("/leaders", handlers.GetLeaders)           // GET /leaders?stat=knockdowns&scope=career&min_fights=10
+	r.Get("/trends", handlers.GetTrends)             // GET /trends?metric=finish_rate&group_by=year&division=lightweight
+	r.Get("/stats/aggregate", handlers.GetAggregate) // GET /stats/aggregate?resource=fights&group_by=year,method&metrics=count,p90:fight_seconds
+	r.Get("/ratings", handlers.ListRatings)          // GET /ratings?division=lightweight
+
+	// defining /referees route, referees are built from the fights they worked
+	r.Route("/referees", func(r chi.Router) {
+		r.Get("/", handlers.ListReferees)     // GET /referees?q=dean
+		r.Get("/{name}", handlers.GetReferee) // GET /referees/herb-dean
+	})
+
 	fmt.Print("[✅ Listening on http://0.0.0.0:8000]\n\n")
 	log.Fatal(http.ListenAndServe("0.0.0.0:8000", r))
 }
@@ -7,9 +7,11 @@ import (
 	apiErrors "github.com/anthonybliss1/ufc-api/api/api_errors"
 	"github.com/anthonybliss1/ufc-api/api/db"
 	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/anthonybliss1/ufc-api/scrape/predict"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type ctxKey string
@@ -20,8 +22,42 @@ const (
 	CtxEventKey         ctxKey = "event"
 	CtxUpcomingEventKey ctxKey = "upcomingEvent"
 	CtxUpcomingFightKey ctxKey = "upcomingFight"
+
+	ctxProjectFieldsKey ctxKey = "projectFields"
 )
 
+// put in front of the loader of a single document's own route (GET /fighters/123): the loader then only
+// fetches what ?fields= selects. nested routes like /fighters/123/fights go without, ?fields= is for their items
+func ProjectFields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ctxProjectFieldsKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// load the document 'id' of a collection. behind ProjectFields only what ?fields= selects is fetched, plus
+// the extra paths its handler reads. an unknown field writes a 400, a missing document a 404
+func loadDoc[T any](w http.ResponseWriter, r *http.Request, coll, id string, extra ...string) (*T, bool) {
+	opts := options.FindOne()
+	if project, _ := r.Context().Value(ctxProjectFieldsKey).(bool); project {
+		proj, err := db.FieldsProjection[T](r, extra...)
+		if err != nil {
+			render.Render(w, r, apiErrors.ErrInvalidRequest(err))
+			return nil, false
+		}
+		if proj != nil {
+			opts.SetProjection(proj)
+		}
+	}
+
+	var doc T
+	if err := db.MongoDB.Collection(coll).FindOne(r.Context(), bson.M{"_id": id}, opts).Decode(&doc); err != nil {
+		render.Render(w, r, apiErrors.ErrNotFound)
+		return nil, false
+	}
+	return &doc, true
+}
+
 func FightCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "fightID")
@@ -30,14 +66,11 @@ func FightCtx(next http.Handler) http.Handler {
 			return
 		}
 
-		var f data.Fight
-		err := db.MongoDB.Collection("fights").FindOne(r.Context(), bson.M{"_id": id}).Decode(&f)
-		if err != nil {
-			render.Render(w, r, apiErrors.ErrNotFound)
+		f, ok := loadDoc[data.Fight](w, r, "fights", id, "event_id", "participants.fighter_id")
+		if !ok {
 			return
 		}
-
-		ctx := context.WithValue(r.Context(), CtxFightKey, &f)
+		ctx := context.WithValue(r.Context(), CtxFightKey, f)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -49,12 +82,11 @@ func FighterCtx(next http.Handler) http.Handler {
 			render.Render(w, r, apiErrors.ErrNotFound)
 			return
 		}
-		var f data.Fighter
-		if err := db.MongoDB.Collection("fighters").FindOne(r.Context(), bson.M{"_id": id}).Decode(&f); err != nil {
-			render.Render(w, r, apiErrors.ErrNotFound)
+		f, ok := loadDoc[data.Fighter](w, r, "fighters", id)
+		if !ok {
 			return
 		}
-		ctx := context.WithValue(r.Context(), CtxFighterKey, &f)
+		ctx := context.WithValue(r.Context(), CtxFighterKey, f)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -66,12 +98,11 @@ func EventCtx(next http.Handler) http.Handler {
 			render.Render(w, r, apiErrors.ErrNotFound)
 			return
 		}
-		var e data.Event
-		if err := db.MongoDB.Collection("events").FindOne(r.Context(), bson.M{"_id": id}).Decode(&e); err != nil {
-			render.Render(w, r, apiErrors.ErrNotFound)
+		e, ok := loadDoc[data.Event](w, r, "events", id)
+		if !ok {
 			return
 		}
-		ctx := context.WithValue(r.Context(), CtxEventKey, &e)
+		ctx := context.WithValue(r.Context(), CtxEventKey, e)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -83,12 +114,11 @@ func UpcomingEventCtx(next http.Handler) http.Handler {
 			render.Render(w, r, apiErrors.ErrNotFound)
 			return
 		}
-		var e data.UpcomingEvent
-		if err := db.MongoDB.Collection("upcomingEvents").FindOne(r.Context(), bson.M{"_id": id}).Decode(&e); err != nil {
-			render.Render(w, r, apiErrors.ErrNotFound)
+		e, ok := loadDoc[data.UpcomingEvent](w, r, "upcomingEvents", id)
+		if !ok {
 			return
 		}
-		ctx := context.WithValue(r.Context(), CtxUpcomingEventKey, &e)
+		ctx := context.WithValue(r.Context(), CtxUpcomingEventKey, e)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -101,14 +131,11 @@ func UpcomingFightCtx(next http.Handler) http.Handler {
 			return
 		}
 
-		var f data.UpcomingFight
-		err := db.MongoDB.Collection("upcomingFights").FindOne(r.Context(), bson.M{"_id": id}).Decode(&f)
-		if err != nil {
-			render.Render(w, r, apiErrors.ErrNotFound)
+		f, ok := loadDoc[data.UpcomingFight](w, r, "upcomingFights", id, predict.UpcomingFightFields...)
+		if !ok {
 			return
 		}
-
-		ctx := context.WithValue(r.Context(), CtxUpcomingFightKey, &f)
+		ctx := context.WithValue(r.Context(), CtxUpcomingFightKey, f)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
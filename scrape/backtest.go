@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/anthonybliss1/ufc-api/scrape/backtest"
+	"github.com/anthonybliss1/ufc-api/scrape/utils"
+)
+
+// 'scrape backtest' replays every stored fight in date order and scores the prediction models on them.
+// models only ever learn from fights on earlier dates than the one they predict
+func runBacktest(args []string) {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+
+	modelList := fs.String("models", strings.Join(backtest.ModelNames(), ","), "comma separated models to compare")
+	from := fs.Int("from", 0, "only score fights from this year on, earlier ones are training only")
+	retrainEvery := fs.Int("retrain-every", backtest.DefaultRetrainEvery, "fight dates between refits of the logistic model")
+	jsonPath := fs.String("json", "", "also write the full report to this json file")
+
+	fs.Parse(args)
+
+	var models []backtest.Model
+	for _, name := range strings.Split(*modelList, ",") {
+		m, err := backtest.NewModel(strings.TrimSpace(name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if lm, ok := m.(*backtest.LogisticModel); ok {
+			lm.RetrainEvery = max(*retrainEvery, 1)
+		}
+		models = append(models, m)
+	}
+
+	ctx := context.Background()
+
+	mc, err := utils.ConnectMongo(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mc.Disconnect(ctx)
+
+	fmt.Println("[Loading Fight History...]")
+
+	bouts, err := backtest.LoadBouts(ctx, mc.Database("ufc"))
+	if err != nil {
+		log.Fatalf("[Backtest Failed: %v]", err)
+	}
+
+	fmt.Printf("[Replaying %d fights...]\n\n", len(bouts))
+
+	results := backtest.Run(bouts, models, backtest.Options{FromYear: *from})
+
+	for _, r := range results {
+		printResult(r)
+	}
+
+	if *jsonPath != "" {
+		raw, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatalf("[Failed to encode report: %v]", err)
+		}
+		if err := os.WriteFile(*jsonPath, raw, 0o644); err != nil {
+			log.Fatalf("[Failed to write report: %v]", err)
+		}
+		fmt.Printf("✅ [Report written to %s]\n", *jsonPath)
+	}
+}
+
+func printMetrics(label string, m *backtest.Metrics) {
+	fmt.Printf("  %-22s %6d  %8.4f  %8.4f  %7.1f%%\n", label, m.Fights, m.LogLoss, m.Brier, m.Accuracy*100)
+}
+
+func printResult(r backtest.Result) {
+	fmt.Printf("=== %s ===\n", r.Model)
+	fmt.Printf("  %-22s %6s  %8s  %8s  %8s\n", "", "fights", "log loss", "brier", "accuracy")
+	printMetrics("overall", r.Overall)
+
+	fmt.Println("\n  calibration (both corners)")
+	for _, b := range r.Overall.Calibration {
+		if b.N == 0 {
+			continue
+		}
+		fmt.Printf("  %.1f-%.1f  %6d  predicted %5.1f%%  won %5.1f%%\n", b.Low, b.High, b.N, b.Predicted*100, b.Observed*100)
+	}
+
+	fmt.Println("\n  by year")
+	for _, k := range backtest.Keys(r.ByYear) {
+		printMetrics(k, r.ByYear[k])
+	}
+
+	fmt.Println("\n  by division")
+	for _, k := range backtest.Keys(r.ByDivision) {
+		printMetrics(k, r.ByDivision[k])
+	}
+	fmt.Println()
+}
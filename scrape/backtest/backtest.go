@@ -0,0 +1,181 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/anthonybliss1/ufc-api/scrape/features"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// calibration buckets, each 0.1 wide
+const buckets = 10
+
+// predictions that fell in one probability range and how often they came true
+type Bucket struct {
+	Low       float64 `json:"low"`
+	High      float64 `json:"high"`
+	N         int     `json:"n"`
+	Predicted float64 `json:"predicted"` // mean predicted probability
+	Observed  float64 `json:"observed"`  // share that won
+}
+
+// scores of a model over a set of decided bouts
+type Metrics struct {
+	Fights      int      `json:"fights"`
+	LogLoss     float64  `json:"log_loss"`
+	Brier       float64  `json:"brier"`
+	Accuracy    float64  `json:"accuracy"`
+	Calibration []Bucket `json:"calibration"`
+
+	sumPredicted []float64
+	sumObserved  []float64
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		Calibration:  make([]Bucket, buckets),
+		sumPredicted: make([]float64, buckets),
+		sumObserved:  make([]float64, buckets),
+	}
+}
+
+// add one prediction (chance that A wins) with its result (1 when A won)
+func (m *Metrics) add(p, y float64) {
+	const eps = 1e-15
+	c := math.Min(math.Max(p, eps), 1-eps)
+
+	m.Fights++
+	m.LogLoss -= y*math.Log(c) + (1-y)*math.Log(1-c)
+	m.Brier += (p - y) * (p - y)
+	switch {
+	case p == 0.5:
+		m.Accuracy += 0.5 // a coin flip is right half the time
+	case (p > 0.5) == (y == 1):
+		m.Accuracy++
+	}
+
+	// both corners go into the calibration so the winner being listed first cannot skew it
+	for _, side := range [][2]float64{{p, y}, {1 - p, 1 - y}} {
+		i := min(int(side[0]*buckets), buckets-1)
+		m.sumPredicted[i] += side[0]
+		m.sumObserved[i] += side[1]
+		m.Calibration[i].N++
+	}
+}
+
+// turn the sums into means
+func (m *Metrics) finish() {
+	if m.Fights > 0 {
+		n := float64(m.Fights)
+		m.LogLoss /= n
+		m.Brier /= n
+		m.Accuracy /= n
+	}
+
+	for i := range m.Calibration {
+		b := &m.Calibration[i]
+		b.Low, b.High = float64(i)/buckets, float64(i+1)/buckets
+		if b.N > 0 {
+			b.Predicted = m.sumPredicted[i] / float64(b.N)
+			b.Observed = m.sumObserved[i] / float64(b.N)
+		}
+	}
+}
+
+// one model's results, overall and split by year and division
+type Result struct {
+	Model      string              `json:"model"`
+	Overall    *Metrics            `json:"overall"`
+	ByYear     map[string]*Metrics `json:"by_year"`
+	ByDivision map[string]*Metrics `json:"by_division"`
+}
+
+type Options struct {
+	FromYear int // bouts before this year only train the models, 0 scores everything
+}
+
+// every two-fighter bout with its pre-fight features, in the order they happened
+func LoadBouts(ctx context.Context, db *mongo.Database) ([]Bout, error) {
+	history, err := data.LoadFightHistory(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	dobs, err := features.LoadDOBs(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.Bouts(history, dobs), nil
+}
+
+// replay the bouts date by date: every model predicts a date's bouts, is scored on the decided ones
+// and only then learns their results
+func Run(bouts []Bout, models []Model, opts Options) []Result {
+	results := make([]Result, len(models))
+	for i, m := range models {
+		results[i] = Result{Model: m.Name(), Overall: newMetrics(), ByYear: map[string]*Metrics{}, ByDivision: map[string]*Metrics{}}
+	}
+
+	group := func(groups map[string]*Metrics, key string) *Metrics {
+		if groups[key] == nil {
+			groups[key] = newMetrics()
+		}
+		return groups[key]
+	}
+
+	features.EachDate(bouts, func(b Bout) int64 { return b.Row.Date.Unix() }, func(day []Bout) {
+		for i, m := range models {
+			for _, b := range day {
+				p := m.Predict(b)
+
+				var y float64
+				switch b.Row.Outcome {
+				case "W":
+					y = 1
+				case "L":
+					y = 0
+				default:
+					continue // draws and no contests are not scored
+				}
+				if b.Row.Date.Year() < opts.FromYear {
+					continue
+				}
+
+				division := b.Row.Division
+				if division == "" {
+					division = "unknown"
+				}
+
+				results[i].Overall.add(p, y)
+				group(results[i].ByYear, strconv.Itoa(b.Row.Date.Year())).add(p, y)
+				group(results[i].ByDivision, division).add(p, y)
+			}
+			m.Update(day)
+		}
+	})
+
+	for _, r := range results {
+		r.Overall.finish()
+		for _, g := range []map[string]*Metrics{r.ByYear, r.ByDivision} {
+			for _, m := range g {
+				m.finish()
+			}
+		}
+	}
+
+	return results
+}
+
+// the keys of a group sorted for printing
+func Keys(groups map[string]*Metrics) []string {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,236 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		preds    [][2]float64 // chance that A wins, 1 when A won
+		logLoss  float64
+		brier    float64
+		accuracy float64
+		buckets  map[int]Bucket // the buckets with predictions, the rest stay empty
+	}{
+		{
+			name:    "confident and right",
+			preds:   [][2]float64{{0.75, 1}},
+			logLoss: 0.2876821, brier: 0.0625, accuracy: 1, // -ln(0.75), 0.25^2
+			buckets: map[int]Bucket{
+				7: {N: 1, Predicted: 0.75, Observed: 1},
+				2: {N: 1, Predicted: 0.25, Observed: 0},
+			},
+		},
+		{
+			name:    "confident and wrong",
+			preds:   [][2]float64{{0.75, 0}},
+			logLoss: 1.3862944, brier: 0.5625, accuracy: 0, // -ln(0.25), 0.75^2
+			buckets: map[int]Bucket{
+				7: {N: 1, Predicted: 0.75, Observed: 0},
+				2: {N: 1, Predicted: 0.25, Observed: 1},
+			},
+		},
+		{
+			name:    "coin flip counts half",
+			preds:   [][2]float64{{0.5, 1}, {0.5, 0}},
+			logLoss: 0.6931472, brier: 0.25, accuracy: 0.5, // ln(2)
+			buckets: map[int]Bucket{
+				5: {N: 4, Predicted: 0.5, Observed: 0.5},
+			},
+		},
+		{
+			name:    "averaged over fights",
+			preds:   [][2]float64{{0.75, 1}, {0.75, 0}, {0.25, 0}},
+			logLoss: 0.6538862, brier: 0.2291667, accuracy: 0.6666667, // (0.2877 + 1.3863 + 0.2877) / 3, (0.0625 + 0.5625 + 0.0625) / 3
+			buckets: map[int]Bucket{
+				7: {N: 3, Predicted: 0.75, Observed: 2.0 / 3},
+				2: {N: 3, Predicted: 0.25, Observed: 1.0 / 3},
+			},
+		},
+		{
+			name:    "certain and right",
+			preds:   [][2]float64{{1, 1}},
+			logLoss: 0, brier: 0, accuracy: 1,
+			buckets: map[int]Bucket{
+				9: {N: 1, Predicted: 1, Observed: 1}, // 1.0 goes into the top bucket
+				0: {N: 1, Predicted: 0, Observed: 0},
+			},
+		},
+		{
+			name:    "certain and wrong is clipped",
+			preds:   [][2]float64{{0, 1}},
+			logLoss: 34.5387764, brier: 1, accuracy: 0, // -ln(1e-15)
+			buckets: map[int]Bucket{
+				0: {N: 1, Predicted: 0, Observed: 1},
+				9: {N: 1, Predicted: 1, Observed: 0},
+			},
+		},
+		{
+			name:    "nothing scored",
+			buckets: map[int]Bucket{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMetrics()
+			for _, p := range tt.preds {
+				m.add(p[0], p[1])
+			}
+			m.finish()
+
+			if m.Fights != len(tt.preds) {
+				t.Errorf("fights = %d, want %d", m.Fights, len(tt.preds))
+			}
+			if !near(m.LogLoss, tt.logLoss) || !near(m.Brier, tt.brier) || !near(m.Accuracy, tt.accuracy) {
+				t.Errorf("log loss %.7f brier %.7f accuracy %.7f, want %.7f, %.7f, %.7f", m.LogLoss, m.Brier, m.Accuracy, tt.logLoss, tt.brier, tt.accuracy)
+			}
+
+			if len(m.Calibration) != buckets {
+				t.Fatalf("%d buckets, want %d", len(m.Calibration), buckets)
+			}
+			for i, b := range m.Calibration {
+				if !near(b.Low, float64(i)/10) || !near(b.High, float64(i+1)/10) {
+					t.Errorf("bucket %d covers %v - %v", i, b.Low, b.High)
+				}
+				w := tt.buckets[i]
+				if b.N != w.N || !near(b.Predicted, w.Predicted) || !near(b.Observed, w.Observed) {
+					t.Errorf("bucket %d = n %d predicted %.4f observed %.4f, want n %d predicted %.4f observed %.4f", i, b.N, b.Predicted, b.Observed, w.N, w.Predicted, w.Observed)
+				}
+			}
+		})
+	}
+}
+
+// writes down every call the harness makes and predicts A wins 75% of the time
+type recorder struct {
+	name  string
+	calls *[]string
+}
+
+func (m recorder) Name() string { return m.name }
+
+func (m recorder) Predict(b Bout) float64 {
+	*m.calls = append(*m.calls, fmt.Sprintf("%s predict %s", m.name, b.Row.FightID))
+	return 0.75
+}
+
+func (m recorder) Update(bouts []Bout) {
+	ids := make([]string, 0, len(bouts))
+	for _, b := range bouts {
+		ids = append(ids, b.Row.FightID)
+	}
+	*m.calls = append(*m.calls, fmt.Sprintf("%s update %s", m.name, strings.Join(ids, ",")))
+}
+
+func replayBout(id string, on time.Time, division, outcome string) Bout {
+	b := Bout{Fight: data.DatedFight{Fight: data.Fight{ID: id}, EventDate: on}}
+	b.Row.FightID, b.Row.Date, b.Row.Division, b.Row.Outcome = id, on, division, outcome
+	return b
+}
+
+func TestRun(t *testing.T) {
+	day1 := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2020, 3, 7, 0, 0, 0, 0, time.UTC)
+	day3 := time.Date(2020, 9, 5, 0, 0, 0, 0, time.UTC)
+
+	bouts := []Bout{
+		replayBout("f1", day1, "lightweight", "W"),
+		replayBout("f2", day1, "lightweight", "L"),
+		replayBout("f3", day2, "lightweight", "W"),
+		replayBout("f4", day2, "", "L"),
+		replayBout("f5", day2, "welterweight", "D"),
+		replayBout("f6", day3, "welterweight", "NC"),
+	}
+
+	// every date is predicted in full before the model learns it, and each model gets the same replay
+	wantCalls := []string{
+		"m predict f1", "m predict f2", "m update f1,f2",
+		"m predict f3", "m predict f4", "m predict f5", "m update f3,f4,f5",
+		"m predict f6", "m update f6",
+	}
+
+	tests := []struct {
+		name      string
+		fromYear  int
+		fights    int
+		logLoss   float64
+		accuracy  float64
+		years     map[string]int
+		divisions map[string]int
+	}{
+		{
+			name:    "everything scored",
+			fights:  4,                        // the draw and the no contest are left out
+			logLoss: 0.8369882, accuracy: 0.5, // (2 * -ln(0.75) + 2 * -ln(0.25)) / 4
+			years:     map[string]int{"2019": 2, "2020": 2},
+			divisions: map[string]int{"lightweight": 3, "unknown": 1},
+		},
+		{
+			name:     "earlier years only train",
+			fromYear: 2020,
+			fights:   2,
+			logLoss:  0.8369882, accuracy: 0.5, // -ln(0.75) and -ln(0.25)
+			years:     map[string]int{"2020": 2},
+			divisions: map[string]int{"lightweight": 1, "unknown": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			results := Run(bouts, []Model{recorder{"m", &calls}}, Options{FromYear: tt.fromYear})
+
+			if strings.Join(calls, "; ") != strings.Join(wantCalls, "; ") {
+				t.Errorf("calls = %q, want %q", calls, wantCalls)
+			}
+			if len(results) != 1 || results[0].Model != "m" {
+				t.Fatalf("results = %+v", results)
+			}
+
+			r := results[0]
+			if r.Overall.Fights != tt.fights || !near(r.Overall.LogLoss, tt.logLoss) || !near(r.Overall.Accuracy, tt.accuracy) {
+				t.Errorf("overall = %d fights, log loss %.7f, accuracy %.7f, want %d, %.7f, %.7f", r.Overall.Fights, r.Overall.LogLoss, r.Overall.Accuracy, tt.fights, tt.logLoss, tt.accuracy)
+			}
+			for _, g := range []struct {
+				groups map[string]*Metrics
+				want   map[string]int
+			}{{r.ByYear, tt.years}, {r.ByDivision, tt.divisions}} {
+				if len(g.groups) != len(g.want) {
+					t.Errorf("groups %v, want %v", Keys(g.groups), g.want)
+				}
+				for k, n := range g.want {
+					if m := g.groups[k]; m == nil || m.Fights != n {
+						t.Errorf("group %q = %+v, want %d fights", k, m, n)
+					}
+				}
+			}
+		})
+	}
+}
+
+// with two models each one predicts and learns a date before the next takes it, they share nothing
+func TestRunInterleavesModels(t *testing.T) {
+	on := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	bouts := []Bout{replayBout("f1", on, "flyweight", "W"), replayBout("f2", on.AddDate(0, 0, 7), "flyweight", "W")}
+
+	var calls []string
+	Run(bouts, []Model{recorder{"a", &calls}, recorder{"b", &calls}}, Options{})
+
+	want := []string{
+		"a predict f1", "a update f1", "b predict f1", "b update f1",
+		"a predict f2", "a update f2", "b predict f2", "b update f2",
+	}
+	if strings.Join(calls, "; ") != strings.Join(want, "; ") {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
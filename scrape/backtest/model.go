@@ -0,0 +1,162 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/anthonybliss1/ufc-api/scrape/features"
+	"github.com/anthonybliss1/ufc-api/scrape/predict"
+	"github.com/anthonybliss1/ufc-api/scrape/ratings"
+)
+
+// one bout of the replay: the pre-fight feature row and the fight itself
+type Bout = features.Bout
+
+// a prediction approach under test. the harness asks for a prediction of every bout on a date first
+// and only then hands the same bouts to Update with their results, so a model never sees a fight
+// before it has predicted it
+type Model interface {
+	Name() string
+	Predict(b Bout) float64 // chance that fighter A (Row.A) wins
+	Update(bouts []Bout)    // the bouts of one date after they were scored, in order
+}
+
+// models that can be picked by name on the command line
+var models = map[string]func() Model{
+	"coinflip": func() Model { return Coinflip{} },
+	"elo":      func() Model { return NewEloModel(ratings.DefaultOptions()) },
+	"logistic": func() Model { return NewLogisticModel(DefaultRetrainEvery) },
+}
+
+// the registered model names, sorted
+func ModelNames() []string {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// a fresh model by name
+func NewModel(name string) (Model, error) {
+	m, ok := models[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown model %q (want one of %s)", name, strings.Join(ModelNames(), ", "))
+	}
+	return m(), nil
+}
+
+// always 50/50, the floor every other model has to beat
+type Coinflip struct{}
+
+func (Coinflip) Name() string         { return "coinflip" }
+func (Coinflip) Predict(Bout) float64 { return 0.5 }
+func (Coinflip) Update([]Bout)        {}
+
+// the Elo ratings the scraper stores, replayed from scratch
+type EloModel struct {
+	elo  *ratings.Elo
+	opts ratings.Options
+}
+
+func NewEloModel(opts ratings.Options) *EloModel {
+	return &EloModel{elo: ratings.NewElo(opts), opts: opts}
+}
+
+func (m *EloModel) Name() string { return m.opts.Model() }
+
+func (m *EloModel) Predict(b Bout) float64 {
+	return m.elo.Expected(b.Row.A.FighterID, b.Row.B.FighterID)
+}
+
+func (m *EloModel) Update(bouts []Bout) {
+	for _, b := range bouts {
+		m.elo.Apply(b.Fight)
+	}
+}
+
+// dates between refits of the logistic model
+const DefaultRetrainEvery = 25
+
+// inputs of the backtest logistic model, A - B like the served model but from the pre-fight numbers
+var logisticFeatures = []string{
+	"rating", "age", "ufc_fights", "streak", "layoff_days", "slpm", "sapm",
+	"str_acc", "str_def", "td_avg", "td_acc", "td_def", "sub_avg",
+}
+
+// the logistic regression of the served model, refit on every decided bout seen so far every
+// RetrainEvery dates. until the first fit it predicts 50/50
+type LogisticModel struct {
+	RetrainEvery int
+
+	elo     *ratings.Elo
+	samples []predict.Sample
+	model   *predict.Model
+	dates   int
+}
+
+func NewLogisticModel(retrainEvery int) *LogisticModel {
+	return &LogisticModel{RetrainEvery: max(retrainEvery, 1), elo: ratings.NewElo(ratings.DefaultOptions())}
+}
+
+func (m *LogisticModel) Name() string { return "logistic" }
+
+// a NaN on either side (no fights yet, unknown birth date) gives 0 like predict.Features
+func diff(a, b float64) float64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return 0
+	}
+	return a - b
+}
+
+func (m *LogisticModel) features(b Bout) []float64 {
+	ra, _ := m.elo.Rating(b.Row.A.FighterID)
+	rb, _ := m.elo.Rating(b.Row.B.FighterID)
+	a, o := b.Row.A, b.Row.B
+
+	return []float64{
+		ra - rb,
+		diff(a.Age, o.Age),
+		float64(a.Fights - o.Fights),
+		float64(a.Streak - o.Streak),
+		diff(a.LayoffDays, o.LayoffDays),
+		diff(a.SLpM, o.SLpM),
+		diff(a.SApM, o.SApM),
+		diff(a.StrAcc, o.StrAcc),
+		diff(a.StrDef, o.StrDef),
+		diff(a.TdAvg, o.TdAvg),
+		diff(a.TdAcc, o.TdAcc),
+		diff(a.TdDef, o.TdDef),
+		diff(a.SubAvg, o.SubAvg),
+	}
+}
+
+func (m *LogisticModel) Predict(b Bout) float64 {
+	if m.model == nil {
+		return 0.5
+	}
+	return m.model.Probability(m.features(b))
+}
+
+func (m *LogisticModel) Update(bouts []Bout) {
+	// features are taken before the ratings move
+	for _, b := range bouts {
+		switch b.Row.Outcome {
+		case "W":
+			m.samples = append(m.samples, predict.Sample{X: m.features(b), Y: 1})
+		case "L":
+			m.samples = append(m.samples, predict.Sample{X: m.features(b), Y: 0})
+		}
+	}
+	for _, b := range bouts {
+		m.elo.Apply(b.Fight)
+	}
+
+	m.dates++
+	if m.dates%m.RetrainEvery == 0 && len(m.samples) > 0 {
+		m.model = predict.Fit(logisticFeatures, predict.Mirror(m.samples), predict.DefaultTrainOptions())
+	}
+}
@@ -0,0 +1,64 @@
+package data
+
+import (
+	"strconv"
+	"strings"
+)
+
+// seconds in a "M:SS" clock value like FightStats.Ctrl or Fight.EndTime. "--" and anything unparsable is 0
+func ClockSeconds(s string) int {
+	m, sec, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0
+	}
+
+	mins, err := strconv.Atoi(m)
+	if err != nil {
+		return 0
+	}
+	secs, err := strconv.Atoi(sec)
+	if err != nil {
+		return 0
+	}
+
+	return mins*60 + secs
+}
+
+// length of a round when the time format does not list them ("No Time Limit")
+const DefaultRoundSeconds = 300
+
+// seconds a fight lasted using the round lengths of its time format, i.e. "3 Rnd (5-5-5)" or
+// "1 Rnd + 2OT (15-3-3)". formats without round lengths use DefaultRoundSeconds, false when the end
+// time does not parse. the api pipelines compute the same with fightSecondsExpr
+func ElapsedSeconds(round int, endTime, timeFormat string) (int, bool) {
+	clock := ClockSeconds(endTime)
+	if clock == 0 || round < 1 {
+		return 0, false
+	}
+
+	var lengths []int
+	if _, inner, ok := strings.Cut(timeFormat, "("); ok {
+		inner, _, _ = strings.Cut(inner, ")")
+		for _, m := range strings.Split(inner, "-") {
+			mins, err := strconv.Atoi(strings.TrimSpace(m))
+			if err != nil {
+				lengths = nil
+				break
+			}
+			lengths = append(lengths, mins*60)
+		}
+	}
+	if lengths == nil {
+		lengths = []int{DefaultRoundSeconds}
+	}
+
+	elapsed := clock
+	for i := 0; i < round-1; i++ {
+		if i < len(lengths) {
+			elapsed += lengths[i]
+		} else {
+			elapsed += lengths[len(lengths)-1]
+		}
+	}
+	return elapsed, true
+}
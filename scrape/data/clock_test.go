@@ -0,0 +1,58 @@
+package data
+
+import "testing"
+
+func TestClockSeconds(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"4:59", 299},
+		{"0:07", 7},
+		{" 12:00 ", 720},
+		{"--", 0},
+		{"", 0},
+		{"5", 0},
+		{"a:10", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ClockSeconds(tt.in); got != tt.want {
+			t.Errorf("ClockSeconds(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestElapsedSeconds(t *testing.T) {
+	tests := []struct {
+		name       string
+		round      int
+		endTime    string
+		timeFormat string
+		want       int
+		ok         bool
+	}{
+		{"first round", 1, "0:13", "3 Rnd (5-5-5)", 13, true},
+		{"third round", 3, "4:59", "3 Rnd (5-5-5)", 899, true},
+		{"championship rounds", 5, "5:00", "5 Rnd (5-5-5-5-5)", 1500, true},
+		{"overtime", 2, "1:30", "1 Rnd + 2OT (15-3-3)", 990, true},
+		{"second overtime", 3, "3:00", "1 Rnd + 2OT (15-3-3)", 1260, true},
+		{"one overtime", 2, "2:00", "1 Rnd + OT (12-3)", 840, true},
+		{"ten minute rounds", 2, "1:00", "2 Rnd (10-5)", 660, true},
+		{"past the listed rounds", 3, "1:00", "2 Rnd (10-5)", 960, true},
+		{"no time limit", 1, "9:20", "No Time Limit", 560, true},
+		{"no time limit second round", 2, "0:30", "No Time Limit", 330, true},
+		{"unparsable lengths", 2, "0:30", "3 Rnd (5-x-5)", 330, true},
+		{"no end time", 2, "--", "3 Rnd (5-5-5)", 0, false},
+		{"no round", 0, "1:00", "3 Rnd (5-5-5)", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ElapsedSeconds(tt.round, tt.endTime, tt.timeFormat)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ElapsedSeconds(%d, %q, %q) = %d, %v, want %d, %v", tt.round, tt.endTime, tt.timeFormat, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -1,14 +1,7 @@
 package data
 
 import (
-	"context"
-	"fmt"
-	"log"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 // creating maps to load scraping data (avoiding dupes) then converted to slice structs for laoding into mongo db
@@ -55,19 +48,25 @@ type Event struct {
 	Name     string    `bson:"name" json:"name"`         // name of the event
 	Date     time.Time `bson:"date" json:"date"`         // date of the event
 	Location string    `bson:"location" json:"location"` // location of the event
+	Card     []string  `bson:"-" json:"-"`               // ids of the fights on the event page, main event first. not stored, fights keep their Bout
 }
 
 type Fight struct {
-	ID           string       `bson:"_id" json:"id"`                      // unique id given to the fight
-	EventID      string       `bson:"event_id" json:"event_id"`           // id of the event
-	FightDetail  string       `bson:"fight_detail" json:"fight_detail"`   // weight class of the given fight sometimes indicates if its a title fight
-	Method       string       `bson:"method" json:"method"`               // winning method of the fight (not for a specific fighter)
-	MethodDetail string       `bson:"method_detail" json:"method_detail"` // details of the winning method for the given fight
-	Round        int          `bson:"round" json:"round"`                 // ending round of the fight
-	EndTime      string       `bson:"end_time" json:"end_time"`           // ending time of the last round of the fight
-	TimeFormat   string       `bson:"time_format" json:"time_format"`     // time format of the fight ie 5 rounds of 5 minutes
-	Referee      string       `bson:"referee" json:"referee"`             // referee for the given fight
-	Participants []FightStats `bson:"participants" json:"participants"`   // slice of fight statistics (for both fighters) for the given fight
+	ID             string       `bson:"_id" json:"id"`                                              // unique id given to the fight
+	EventID        string       `bson:"event_id" json:"event_id"`                                   // id of the event
+	Date           *time.Time   `bson:"date,omitempty" json:"date,omitempty"`                       // date of the event, copied onto the fight so fight lists can sort by it (see BackfillFightDates)
+	Bout           int          `bson:"bout,omitempty" json:"bout,omitempty"`                       // position on the event card, 1 for the main event (0 when unknown)
+	FightDetail    string       `bson:"fight_detail" json:"fight_detail"`                           // weight class of the given fight sometimes indicates if its a title fight
+	Division       string       `bson:"division,omitempty" json:"division,omitempty"`               // division slug parsed from the fight detail (see DivisionOf)
+	Method         string       `bson:"method" json:"method"`                                       // winning method of the fight (not for a specific fighter)
+	MethodDetail   string       `bson:"method_detail" json:"method_detail"`                         // details of the winning method for the given fight
+	MethodCategory string       `bson:"method_category,omitempty" json:"method_category,omitempty"` // normalized method, i.e. KO_TKO or DECISION_SPLIT (see MethodCategoryOf)
+	Technique      string       `bson:"technique,omitempty" json:"technique,omitempty"`             // finishing submission or strike, i.e. "Rear Naked Choke" (see TechniqueOf)
+	Round          int          `bson:"round" json:"round"`                                         // ending round of the fight
+	EndTime        string       `bson:"end_time" json:"end_time"`                                   // ending time of the last round of the fight
+	TimeFormat     string       `bson:"time_format" json:"time_format"`                             // time format of the fight ie 5 rounds of 5 minutes
+	Referee        string       `bson:"referee" json:"referee"`                                     // referee for the given fight
+	Participants   []FightStats `bson:"participants" json:"participants"`                           // slice of fight statistics (for both fighters) for the given fight
 }
 
 type FightStats struct {
@@ -153,57 +152,3 @@ func (ue *UpcomingEvent) SetID(id string) { ue.ID = id }
 
 func (uf *UpcomingFight) GetID() string   { return uf.ID }
 func (uf *UpcomingFight) SetID(id string) { uf.ID = id }
-
-// upload data into mongodb collection for either FighterMap, EventMap, or FightMap with values of 'IDable'. default batch size is 1000
-func BatchLoad[T IDable](ctx context.Context, coll *mongo.Collection, m map[string]T, batchSize int) error {
-	if len(m) == 0 {
-		fmt.Printf("[%s data is empty, now exiting...]\n", coll.Name())
-		return nil
-	}
-
-	// if dealing with any of the upcoming collections, drop first to clear the data
-	if coll.Name() == "upcomingEvents" || coll.Name() == "upcomingFights" {
-		if err := coll.Drop(ctx); err != nil {
-			log.Fatalf("cannot drop [%s]: %v", coll.Name(), err)
-		} else {
-			fmt.Printf("[%s dropped successfully...]\n", coll.Name())
-		}
-	}
-
-	//setting default batch size
-	if batchSize <= 0 {
-		batchSize = 1000
-	}
-
-	batch := make([]mongo.WriteModel, 0, batchSize)
-
-	// looping through each struct in the map
-	for k, v := range m {
-		v.SetID(k)
-
-		// define batch
-		batch = append(batch, mongo.NewReplaceOneModel().
-			SetFilter(bson.M{"_id": v.GetID()}).
-			SetReplacement(v).
-			SetUpsert(true))
-
-		// batch upload when batch exceeds defined batch size
-		if len(batch) >= batchSize {
-			if _, err := coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false)); err != nil {
-				return fmt.Errorf("bulk write failed: %v", err)
-			}
-			batch = batch[:0]
-		}
-	}
-
-	// final batch upload if it does not exceed batch size
-	if len(batch) > 0 {
-		if _, err := coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false)); err != nil {
-			return fmt.Errorf("final bulk write failed: %v", err)
-		}
-	}
-
-	fmt.Printf("✅ [%s data loaded successfully!]\n", coll.Name())
-
-	return nil
-}
@@ -0,0 +1,108 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// field stored on every loaded document so unchanged documents can be skipped on the next load
+const HashField = "content_hash"
+
+// a single field that differs between the stored and the newly scraped document
+type FieldChange struct {
+	Field string `bson:"field" json:"field"` // dot path, array elements by index (i.e participants.0.kd)
+	Old   any    `bson:"old" json:"old"`     // nil when the field is new
+	New   any    `bson:"new" json:"new"`     // nil when the field was removed
+}
+
+// an updated document and every field that changed
+type DocChange struct {
+	ID     string        `bson:"id" json:"id"`
+	Fields []FieldChange `bson:"fields" json:"fields"`
+}
+
+// hash of the marshalled document, struct fields always marshal in the same order so equal content gives an equal hash
+func ContentHash(raw bson.Raw) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// compare two documents field by field, ignoring the content hash
+func DiffDocs(old, new bson.Raw) []FieldChange {
+	before := make(map[string]bson.RawValue)
+	after := make(map[string]bson.RawValue)
+	flatten("", old, before)
+	flatten("", new, after)
+
+	paths := make([]string, 0, len(after))
+	for p := range after {
+		paths = append(paths, p)
+	}
+	for p := range before {
+		if _, ok := after[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []FieldChange
+	for _, p := range paths {
+		if p == HashField {
+			continue
+		}
+
+		o, hadOld := before[p]
+		n, hasNew := after[p]
+		if hadOld && hasNew && o.Equal(n) {
+			continue
+		}
+
+		change := FieldChange{Field: p}
+		if hadOld {
+			change.Old = rawToValue(o)
+		}
+		if hasNew {
+			change.New = rawToValue(n)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// collect every leaf value of doc under its dot path. arrays are documents keyed by index so they flatten the same way
+func flatten(prefix string, doc bson.Raw, out map[string]bson.RawValue) {
+	elems, err := doc.Elements()
+	if err != nil {
+		return
+	}
+
+	for _, e := range elems {
+		path := e.Key()
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		v := e.Value()
+		if sub, ok := v.DocumentOK(); ok {
+			flatten(path, sub, out)
+			continue
+		}
+		if arr, ok := v.ArrayOK(); ok {
+			flatten(path, bson.Raw(arr), out)
+			continue
+		}
+		out[path] = v
+	}
+}
+
+func rawToValue(v bson.RawValue) any {
+	var out any
+	if err := v.Unmarshal(&out); err != nil {
+		return v.String()
+	}
+	return out
+}
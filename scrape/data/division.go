@@ -0,0 +1,93 @@
+package data
+
+import (
+	"strings"
+	"time"
+)
+
+// a weight class of the division taxonomy
+type Division struct {
+	Slug    string `json:"slug"`               // i.e. "lightweight", "womens-strawweight"
+	Name    string `json:"name"`               // i.e. "Lightweight", "Women's Strawweight"
+	Gender  string `json:"gender"`             // "men" or "women"
+	LimitLB int    `json:"limit_lb,omitempty"` // upper weight limit, 0 when there is none
+	Ranked  bool   `json:"ranked"`             // false for catch and open weight bouts, which count towards no division
+}
+
+// every division, lightest first
+var Divisions = []Division{
+	{"womens-strawweight", "Women's Strawweight", "women", 115, true},
+	{"womens-flyweight", "Women's Flyweight", "women", 125, true},
+	{"womens-bantamweight", "Women's Bantamweight", "women", 135, true},
+	{"womens-featherweight", "Women's Featherweight", "women", 145, true},
+	{"strawweight", "Strawweight", "men", 115, true},
+	{"flyweight", "Flyweight", "men", 125, true},
+	{"bantamweight", "Bantamweight", "men", 135, true},
+	{"featherweight", "Featherweight", "men", 145, true},
+	{"lightweight", "Lightweight", "men", 155, true},
+	{"welterweight", "Welterweight", "men", 170, true},
+	{"middleweight", "Middleweight", "men", 185, true},
+	{"light-heavyweight", "Light Heavyweight", "men", 205, true},
+	{"heavyweight", "Heavyweight", "men", 265, true},
+	{"super-heavyweight", "Super Heavyweight", "men", 0, true},
+	{"womens-catch-weight", "Women's Catch Weight", "women", 0, false},
+	{"catch-weight", "Catch Weight", "men", 0, false},
+	{"womens-open-weight", "Women's Open Weight", "women", 0, false},
+	{"open-weight", "Open Weight", "men", 0, false},
+}
+
+// the division with the given slug
+func DivisionBySlug(slug string) (Division, bool) {
+	for _, d := range Divisions {
+		if d.Slug == slug {
+			return d, true
+		}
+	}
+	return Division{}, false
+}
+
+// weight classes as they appear in Fight.FightDetail. longer names come first so "light heavyweight" and
+// "super heavyweight" are not read as "heavyweight"
+var weightClasses = []string{
+	"light heavyweight",
+	"super heavyweight",
+	"heavyweight",
+	"middleweight",
+	"welterweight",
+	"lightweight",
+	"featherweight",
+	"bantamweight",
+	"flyweight",
+	"strawweight",
+	"catch weight",
+	"open weight",
+}
+
+// division slug of a fight from its fight_detail, i.e. "UFC Women's Bantamweight Title Bout" -> "womens-bantamweight".
+// empty when no weight class is mentioned (early tournament bouts)
+func DivisionOf(fightDetail string) string {
+	detail := strings.ToLower(fightDetail)
+
+	for _, wc := range weightClasses {
+		if !strings.Contains(detail, wc) {
+			continue
+		}
+		slug := strings.ReplaceAll(wc, " ", "-")
+		if strings.Contains(detail, "women") {
+			slug = "womens-" + slug
+		}
+		return slug
+	}
+
+	return ""
+}
+
+// the divisions a fighter competes in, derived from their fights after every load ('fighterDivisions' collection)
+type FighterDivision struct {
+	ID        string         `bson:"_id" json:"fighter_id"`
+	Name      string         `bson:"fighter_name" json:"fighter_name"`
+	Primary   string         `bson:"primary,omitempty" json:"primary,omitempty"` // most fights among their recent ones
+	Current   string         `bson:"current,omitempty" json:"current,omitempty"` // division of their newest fight
+	Fights    map[string]int `bson:"fights" json:"fights"`                       // UFC fights per division slug
+	LastFight time.Time      `bson:"last_fight" json:"last_fight"`
+}
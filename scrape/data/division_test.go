@@ -0,0 +1,59 @@
+package data
+
+import "testing"
+
+func TestDivisionOf(t *testing.T) {
+	tests := []struct {
+		detail string
+		want   string
+	}{
+		{"Light Heavyweight Bout", "light-heavyweight"},
+		{"UFC Light Heavyweight Title Bout", "light-heavyweight"},
+		{"Heavyweight Bout", "heavyweight"},
+		{"UFC Interim Heavyweight Title Bout", "heavyweight"},
+		{"Super Heavyweight Bout", "super-heavyweight"},
+		{"Lightweight Bout", "lightweight"},
+		{"Ultimate Fighter 28 Heavyweight Tournament Title Bout", "heavyweight"},
+		{"UFC Women's Bantamweight Title Bout", "womens-bantamweight"},
+		{"Women's Strawweight Bout", "womens-strawweight"},
+		{"Women's Catch Weight Bout", "womens-catch-weight"},
+		{"Catch Weight Bout", "catch-weight"},
+		{"Open Weight Bout", "open-weight"},
+		{"UFC 2 Tournament Bout", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.detail, func(t *testing.T) {
+			got := DivisionOf(tt.detail)
+			if got != tt.want {
+				t.Errorf("DivisionOf(%q) = %q, want %q", tt.detail, got, tt.want)
+			}
+			if _, ok := DivisionBySlug(got); got != "" && !ok {
+				t.Errorf("DivisionOf(%q) = %q is not in Divisions", tt.detail, got)
+			}
+		})
+	}
+}
+
+func TestDivisionBySlug(t *testing.T) {
+	tests := []struct {
+		slug   string
+		name   string
+		ranked bool
+		ok     bool
+	}{
+		{"light-heavyweight", "Light Heavyweight", true, true},
+		{"womens-flyweight", "Women's Flyweight", true, true},
+		{"catch-weight", "Catch Weight", false, true},
+		{"Lightweight", "", false, false},
+		{"", "", false, false},
+	}
+
+	for _, tt := range tests {
+		d, ok := DivisionBySlug(tt.slug)
+		if ok != tt.ok || d.Name != tt.name || d.Ranked != tt.ranked {
+			t.Errorf("DivisionBySlug(%q) = %+v, %v", tt.slug, d, ok)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// copy the event date onto the fights stored without one (loaded before fights carried it), one update
+// per event. returns the number of fights updated
+func BackfillFightDates(ctx context.Context, db *mongo.Database) (int, error) {
+	coll := db.Collection("fights")
+
+	var eventIDs []string
+	if err := coll.Distinct(ctx, "event_id", bson.M{"date": bson.M{"$exists": false}}).Decode(&eventIDs); err != nil {
+		return 0, fmt.Errorf("failed to read fight events: %v", err)
+	}
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+
+	cur, err := db.Collection("events").Find(ctx, bson.M{"_id": bson.M{"$in": eventIDs}}, options.Find().SetProjection(bson.M{"date": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read events: %v", err)
+	}
+	var events []Event
+	if err := cur.All(ctx, &events); err != nil {
+		return 0, fmt.Errorf("decode events failed: %v", err)
+	}
+
+	updated := 0
+	for _, e := range events {
+		if e.Date.IsZero() {
+			continue
+		}
+		res, err := coll.UpdateMany(ctx,
+			bson.M{"event_id": e.ID, "date": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"date": e.Date}},
+		)
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill fight dates: %v", err)
+		}
+		updated += int(res.ModifiedCount)
+	}
+	return updated, nil
+}
+
+// a fight with the date of its event. Fight.Date points at the same date once loaded
+type DatedFight struct {
+	Fight
+	EventDate time.Time
+}
+
+// every stored fight with a known event date, in the order they happened (event date, then fight id
+// so fights on the same card always replay the same way)
+func LoadFightHistory(ctx context.Context, db *mongo.Database) ([]DatedFight, error) {
+	return loadHistory(ctx, db, bson.M{})
+}
+
+// the fights of some fighters, in the order of LoadFightHistory
+func LoadFighterHistory(ctx context.Context, db *mongo.Database, fighterIDs []string) ([]DatedFight, error) {
+	return loadHistory(ctx, db, bson.M{"participants.fighter_id": bson.M{"$in": fighterIDs}})
+}
+
+func loadHistory(ctx context.Context, db *mongo.Database, filter bson.M) ([]DatedFight, error) {
+	dates := make(map[string]time.Time)
+
+	cur, err := db.Collection("events").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"date": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %v", err)
+	}
+	var events []Event
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("decode events failed: %v", err)
+	}
+	for _, e := range events {
+		dates[e.ID] = e.Date
+	}
+
+	cur, err = db.Collection("fights").Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fights: %v", err)
+	}
+	var fights []Fight
+	if err := cur.All(ctx, &fights); err != nil {
+		return nil, fmt.Errorf("decode fights failed: %v", err)
+	}
+
+	history := make([]DatedFight, 0, len(fights))
+	for _, f := range fights {
+		date, ok := dates[f.EventID]
+		if !ok || date.IsZero() {
+			continue
+		}
+		f.Date = &date
+		history = append(history, DatedFight{Fight: f, EventDate: date})
+	}
+
+	slices.SortFunc(history, func(a, b DatedFight) int {
+		if c := a.EventDate.Compare(b.EventDate); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return history, nil
+}
@@ -0,0 +1,22 @@
+package data
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// index definitions for the upcoming collections. shared by the api (EnsureIndexes) and the scraper,
+// which builds them on the staging collections before swapping them in
+var (
+	UpcomingEventIndexes = []mongo.IndexModel{
+		{Keys: bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "name", Value: 1}}},
+		{Keys: bson.D{{Key: "location", Value: 1}}},
+	}
+
+	UpcomingFightIndexes = []mongo.IndexModel{
+		{Keys: bson.D{{Key: "upcoming_event_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "tale_of_the_tape._id", Value: 1}, {Key: "_id", Value: 1}}},  // if embedded fighters have _id
+		{Keys: bson.D{{Key: "tale_of_the_tape.name", Value: 1}, {Key: "_id", Value: 1}}}, // helps name filters a bit
+	}
+)
@@ -0,0 +1,444 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// load modes reported in RunSummary
+const (
+	ModeTransaction = "transaction" // everything committed or nothing (replica sets / sharded clusters)
+	ModeChunked     = "chunked"     // independent bulk writes of 'batchSize' documents
+)
+
+// a document that could not be written and the reason mongo gave
+type DocError struct {
+	ID      string `bson:"id" json:"id"`
+	Code    int    `bson:"code,omitempty" json:"code,omitempty"`
+	Message string `bson:"message" json:"message"`
+}
+
+// outcome of loading one collection, including its change log. the id lists are not part of the
+// 'changeLogs' document, StoreChangeLog writes them to 'changeLogEntries'
+type LoadSummary struct {
+	Collection string      `bson:"collection" json:"collection"`
+	Inserted   int64       `bson:"inserted" json:"inserted"`   // documents that did not exist before
+	Replaced   int64       `bson:"replaced" json:"replaced"`   // existing documents that were rewritten
+	Unchanged  int64       `bson:"unchanged" json:"unchanged"` // existing documents that were identical and skipped
+	Rejected   int64       `bson:"rejected" json:"rejected"`   // documents that failed, len(Failed) once stored
+	Added      []string    `bson:"-" json:"added,omitempty"`   // ids of the new documents
+	Updated    []DocChange `bson:"-" json:"updated,omitempty"` // ids and field level diffs of the changed documents
+	Failed     []DocError  `bson:"-" json:"failed,omitempty"`  // documents that were rejected
+}
+
+// entries of the change log lists per 'changeLogEntries' document, keeps every document far below mongo's 16MB
+const changeLogPartSize = 500
+
+// part of the id lists of one collection of a run, in the 'changeLogEntries' collection
+type ChangeLogPart struct {
+	RunID      bson.ObjectID `bson:"run_id"`
+	Collection string        `bson:"collection"`
+	Part       int           `bson:"part"`
+	Added      []string      `bson:"added,omitempty"`
+	Updated    []DocChange   `bson:"updated,omitempty"`
+	Failed     []DocError    `bson:"failed,omitempty"`
+}
+
+// store the change log of a run: the summary with the counts of every collection in 'changeLogs', the added,
+// updated and failed lists split over 'changeLogEntries' documents of changeLogPartSize entries
+func StoreChangeLog(ctx context.Context, db *mongo.Database, summary *RunSummary) error {
+	for _, c := range summary.Collections {
+		c.Rejected = int64(len(c.Failed))
+	}
+	if _, err := db.Collection("changeLogs").InsertOne(ctx, summary); err != nil {
+		return fmt.Errorf("failed to store change log: %v", err)
+	}
+
+	var parts []any
+	for _, c := range summary.Collections {
+		for i := 0; i < max(len(c.Added), len(c.Updated), len(c.Failed)); i += changeLogPartSize {
+			parts = append(parts, ChangeLogPart{
+				RunID:      summary.ID,
+				Collection: c.Collection,
+				Part:       i / changeLogPartSize,
+				Added:      window(c.Added, i),
+				Updated:    window(c.Updated, i),
+				Failed:     window(c.Failed, i),
+			})
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	if _, err := db.Collection("changeLogEntries").InsertMany(ctx, parts); err != nil {
+		return fmt.Errorf("failed to store change log entries: %v", err)
+	}
+	return nil
+}
+
+// the changeLogPartSize entries of s starting at i, nil past its end
+func window[T any](s []T, i int) []T {
+	if i >= len(s) {
+		return nil
+	}
+	return s[i:min(i+changeLogPartSize, len(s))]
+}
+
+// machine readable outcome of an entire load (RunBatches), stored in the 'changeLogs' collection
+type RunSummary struct {
+	ID          bson.ObjectID  `bson:"_id" json:"-"`
+	Mode        string         `bson:"mode" json:"mode"`
+	RolledBack  bool           `bson:"rolled_back" json:"rolled_back"` // the transaction was aborted and nothing was written
+	StartedAt   time.Time      `bson:"started_at" json:"started_at"`
+	FinishedAt  time.Time      `bson:"finished_at" json:"finished_at"`
+	Collections []*LoadSummary `bson:"collections" json:"collections"`
+	Error       string         `bson:"error,omitempty" json:"error,omitempty"`
+	DerivedAt   *time.Time     `bson:"derived_at,omitempty" json:"derived_at,omitempty"` // when the derived collections were rebuilt from this load
+}
+
+// a chunked run starting now, with the id of its changeLogs document
+func NewRunSummary() *RunSummary {
+	return &RunSummary{ID: bson.NewObjectID(), Mode: ModeChunked, StartedAt: time.Now().UTC()}
+}
+
+// record that the derived collections (ratings, divisions, records...) were rebuilt after the load. the api
+// drops its caches on this marker and not on the load itself, so nothing is cached from half rebuilt data.
+// upserts so the marker is written even when the change log could not be stored
+func MarkDerived(ctx context.Context, db *mongo.Database, summary *RunSummary) error {
+	now := time.Now().UTC()
+	_, err := db.Collection("changeLogs").UpdateOne(ctx,
+		bson.M{"_id": summary.ID},
+		bson.M{"$set": bson.M{"derived_at": now}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark derived data: %v", err)
+	}
+	summary.DerivedAt = &now
+	return nil
+}
+
+// returned when a load finished but some documents were rejected
+var ErrPartialLoad = errors.New("some documents failed to load")
+
+// transactions need a replica set or a sharded cluster, a standalone server has neither
+func SupportsTransactions(ctx context.Context, client *mongo.Client) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
+
+// upload data into mongodb collection for either FighterMap, EventMap, or FightMap with values of 'IDable'. default batch size is 1000.
+// every document is stored with a content hash, documents whose hash did not change are skipped and changed documents
+// are diffed against the stored version. when ctx carries a transaction the load stops at the first failed chunk since
+// the server aborts the transaction anyway
+func BatchLoad[T IDable](ctx context.Context, coll *mongo.Collection, m map[string]T, batchSize int) (*LoadSummary, error) {
+	summary := &LoadSummary{Collection: coll.Name()}
+
+	if len(m) == 0 {
+		fmt.Printf("[%s data is empty, now exiting...]\n", coll.Name())
+		return summary, nil
+	}
+
+	//setting default batch size
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	inTx := mongo.SessionFromContext(ctx) != nil
+
+	chunk := make([]hashedDoc, 0, batchSize)
+
+	// looping through each struct in the map
+	for k, v := range m {
+		v.SetID(k)
+
+		raw, err := bson.Marshal(v)
+		if err != nil {
+			summary.Failed = append(summary.Failed, DocError{ID: k, Message: err.Error()})
+			continue
+		}
+		chunk = append(chunk, hashedDoc{id: k, raw: raw, hash: ContentHash(raw)})
+
+		// batch upload when batch exceeds defined batch size
+		if len(chunk) >= batchSize {
+			if err := loadChunk(ctx, coll, chunk, summary); err != nil {
+				return summary, fmt.Errorf("bulk write failed: %w", err)
+			}
+			if inTx && len(summary.Failed) > 0 {
+				return summary, ErrPartialLoad
+			}
+			chunk = chunk[:0]
+		}
+	}
+
+	// final batch upload if it does not exceed batch size
+	if len(chunk) > 0 {
+		if err := loadChunk(ctx, coll, chunk, summary); err != nil {
+			return summary, fmt.Errorf("final bulk write failed: %w", err)
+		}
+	}
+
+	if len(summary.Failed) > 0 {
+		fmt.Printf("❌ [%s: %d documents failed to load]\n", coll.Name(), len(summary.Failed))
+		return summary, ErrPartialLoad
+	}
+
+	fmt.Printf("✅ [%s data loaded successfully!]\n", coll.Name())
+
+	return summary, nil
+}
+
+type hashedDoc struct {
+	id   string
+	raw  bson.Raw
+	hash string
+}
+
+// compare a chunk against the stored hashes, record what changed and write everything that is new or different
+func loadChunk(ctx context.Context, coll *mongo.Collection, chunk []hashedDoc, summary *LoadSummary) error {
+	ids := make([]string, 0, len(chunk))
+	for _, d := range chunk {
+		ids = append(ids, d.id)
+	}
+
+	stored, err := findStored(ctx, coll, ids, bson.M{HashField: 1})
+	if err != nil {
+		return err
+	}
+
+	batch := make([]mongo.WriteModel, 0, len(chunk))
+	batchIDs := make([]string, 0, len(chunk))
+	added := make([]string, 0)
+	changed := make(map[string]bson.Raw)
+
+	for _, d := range chunk {
+		old, exists := stored[d.id]
+		oldHash, _ := old.Lookup(HashField).StringValueOK()
+
+		switch {
+		case !exists:
+			added = append(added, d.id)
+		case oldHash == d.hash:
+			summary.Unchanged++
+			continue
+		default:
+			changed[d.id] = d.raw
+		}
+
+		doc, err := withHash(d.raw, d.hash)
+		if err != nil {
+			summary.Failed = append(summary.Failed, DocError{ID: d.id, Message: err.Error()})
+			continue
+		}
+
+		batch = append(batch, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": d.id}).
+			SetReplacement(doc).
+			SetUpsert(true))
+		batchIDs = append(batchIDs, d.id)
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// only changed documents are fetched in full to build their diffs
+	var updated []DocChange
+	if len(changed) > 0 {
+		changedIDs := make([]string, 0, len(changed))
+		for id := range changed {
+			changedIDs = append(changedIDs, id)
+		}
+
+		olds, err := findStored(ctx, coll, changedIDs, nil)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range changedIDs {
+			// documents stored before hashing was added only gain the hash, they are not reported as updates
+			if fields := DiffDocs(olds[id], changed[id]); len(fields) > 0 {
+				updated = append(updated, DocChange{ID: id, Fields: fields})
+			}
+		}
+	}
+
+	failedBefore := len(summary.Failed)
+	if err := writeBatch(ctx, coll, batch, batchIDs, summary); err != nil {
+		return err
+	}
+
+	// leave rejected documents out of the change log
+	failed := make(map[string]bool)
+	for _, f := range summary.Failed[failedBefore:] {
+		failed[f.ID] = true
+	}
+	for _, id := range added {
+		if !failed[id] {
+			summary.Added = append(summary.Added, id)
+		}
+	}
+	for _, c := range updated {
+		if !failed[c.ID] {
+			summary.Updated = append(summary.Updated, c)
+		}
+	}
+
+	return nil
+}
+
+// load the stored documents for ids, keyed by id. a nil projection returns the whole document
+func findStored(ctx context.Context, coll *mongo.Collection, ids []string, projection any) (map[string]bson.Raw, error) {
+	opts := options.Find()
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
+
+	cur, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored documents: %v", err)
+	}
+	defer cur.Close(ctx)
+
+	out := make(map[string]bson.Raw, len(ids))
+	for cur.Next(ctx) {
+		raw := make(bson.Raw, len(cur.Current))
+		copy(raw, cur.Current)
+		id, _ := raw.Lookup("_id").StringValueOK()
+		out[id] = raw
+	}
+
+	return out, cur.Err()
+}
+
+// the marshalled document with its content hash appended
+func withHash(raw bson.Raw, hash string) (bson.D, error) {
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return append(doc, bson.E{Key: HashField, Value: hash}), nil
+}
+
+// run one unordered bulk write and add its outcome to the summary. rejected documents are recorded
+// by id, any other error (network, aborted transaction) is returned
+func writeBatch(ctx context.Context, coll *mongo.Collection, batch []mongo.WriteModel, ids []string, summary *LoadSummary) error {
+	res, err := coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+	if res != nil {
+		summary.Inserted += res.UpsertedCount + res.InsertedCount
+		summary.Replaced += res.ModifiedCount
+		summary.Unchanged += res.MatchedCount - res.ModifiedCount
+	}
+	if err == nil {
+		return nil
+	}
+
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0 {
+		return err
+	}
+
+	for _, we := range bwe.WriteErrors {
+		id := ""
+		if we.Index >= 0 && we.Index < len(ids) {
+			id = ids[we.Index]
+		}
+		summary.Failed = append(summary.Failed, DocError{ID: id, Code: we.Code, Message: we.Message})
+	}
+
+	return nil
+}
+
+// replace the entire contents of a collection (used for the upcoming collections). the data is written into
+// '<name>_staging' with its indexes already built, then swapped in with renameCollection (dropTarget) so
+// readers see either the old or the new data, never an empty collection. a failed load leaves the old data in place
+func SwapLoad[T IDable](ctx context.Context, db *mongo.Database, name string, m map[string]T, indexes []mongo.IndexModel, batchSize int) (*LoadSummary, error) {
+	summary := &LoadSummary{Collection: name}
+
+	if len(m) == 0 {
+		fmt.Printf("[%s data is empty, now exiting...]\n", name)
+		return summary, nil
+	}
+
+	staging := db.Collection(name + "_staging")
+
+	// clear anything left behind by a previous failed load
+	if err := staging.Drop(ctx); err != nil {
+		return summary, fmt.Errorf("cannot drop [%s]: %v", staging.Name(), err)
+	}
+
+	if err := loadStaging(ctx, staging, m, indexes, batchSize, summary); err != nil {
+		staging.Drop(ctx)
+		summary.Inserted = 0 // nothing reached the live collection
+		return summary, err
+	}
+
+	rename := bson.D{
+		{Key: "renameCollection", Value: db.Name() + "." + staging.Name()},
+		{Key: "to", Value: db.Name() + "." + name},
+		{Key: "dropTarget", Value: true},
+	}
+	if err := db.Client().Database("admin").RunCommand(ctx, rename).Err(); err != nil {
+		staging.Drop(ctx)
+		summary.Inserted = 0
+		return summary, fmt.Errorf("cannot swap [%s] into [%s]: %v", staging.Name(), name, err)
+	}
+
+	fmt.Printf("✅ [%s data swapped in successfully!]\n", name)
+
+	return summary, nil
+}
+
+func loadStaging[T IDable](ctx context.Context, staging *mongo.Collection, m map[string]T, indexes []mongo.IndexModel, batchSize int, summary *LoadSummary) error {
+	// build the indexes first so the collection is ready to serve the moment it is renamed
+	if len(indexes) > 0 {
+		if _, err := staging.Indexes().CreateMany(ctx, indexes); err != nil {
+			return fmt.Errorf("cannot create indexes on [%s]: %v", staging.Name(), err)
+		}
+	}
+
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	batch := make([]mongo.WriteModel, 0, batchSize)
+	ids := make([]string, 0, batchSize)
+
+	for k, v := range m {
+		v.SetID(k)
+		batch = append(batch, mongo.NewInsertOneModel().SetDocument(v))
+		ids = append(ids, k)
+
+		if len(batch) >= batchSize {
+			if err := writeBatch(ctx, staging, batch, ids, summary); err != nil {
+				return fmt.Errorf("bulk write failed: %w", err)
+			}
+			batch, ids = batch[:0], ids[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := writeBatch(ctx, staging, batch, ids, summary); err != nil {
+			return fmt.Errorf("final bulk write failed: %w", err)
+		}
+	}
+
+	// a partial upcoming list is worse than yesterday's complete one
+	if len(summary.Failed) > 0 {
+		return ErrPartialLoad
+	}
+
+	return nil
+}
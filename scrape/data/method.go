@@ -0,0 +1,173 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// normalized Fight.Method values
+const (
+	MethodKOTKO             = "KO_TKO"
+	MethodDoctorStoppage    = "TKO_DOCTOR_STOPPAGE"
+	MethodSubmission        = "SUBMISSION"
+	MethodDecisionUnanimous = "DECISION_UNANIMOUS"
+	MethodDecisionSplit     = "DECISION_SPLIT"
+	MethodDecisionMajority  = "DECISION_MAJORITY"
+	MethodDisqualification  = "DISQUALIFICATION"
+	MethodOverturned        = "OVERTURNED"
+	MethodCouldNotContinue  = "COULD_NOT_CONTINUE"
+	MethodOther             = "OTHER"
+)
+
+var MethodCategories = []string{
+	MethodKOTKO,
+	MethodDoctorStoppage,
+	MethodSubmission,
+	MethodDecisionUnanimous,
+	MethodDecisionSplit,
+	MethodDecisionMajority,
+	MethodDisqualification,
+	MethodOverturned,
+	MethodCouldNotContinue,
+	MethodOther,
+}
+
+// categories of the fights that ended before the judges
+var FinishCategories = []string{MethodKOTKO, MethodDoctorStoppage, MethodSubmission}
+
+// true when the method ended the fight before the judges, in either spelling ("Submission" or "SUB")
+func IsFinish(method string) bool {
+	return slices.Contains(FinishCategories, MethodCategoryOf(method))
+}
+
+// categories of the fights the judges decided
+var DecisionCategories = []string{MethodDecisionUnanimous, MethodDecisionSplit, MethodDecisionMajority}
+
+// method category of a raw Fight.Method, both the long ("Decision - Split") and the short ("S-DEC")
+// spellings. empty when there is no method
+func MethodCategoryOf(method string) string {
+	m := strings.ToLower(strings.TrimSpace(method))
+
+	switch {
+	case m == "":
+		return ""
+	case strings.Contains(m, "doctor"):
+		return MethodDoctorStoppage
+	case strings.HasPrefix(m, "ko") || strings.HasPrefix(m, "tko"):
+		return MethodKOTKO
+	case strings.HasPrefix(m, "sub"):
+		return MethodSubmission
+	case m == "u-dec" || strings.Contains(m, "unanimous"):
+		return MethodDecisionUnanimous
+	case m == "s-dec" || strings.Contains(m, "split"):
+		return MethodDecisionSplit
+	case m == "m-dec" || strings.Contains(m, "majority"):
+		return MethodDecisionMajority
+	case m == "dq" || strings.Contains(m, "disqualif"):
+		return MethodDisqualification
+	case strings.Contains(m, "overturned"):
+		return MethodOverturned
+	case strings.Contains(m, "could not continue"):
+		return MethodCouldNotContinue
+	}
+	return MethodOther
+}
+
+// target and position of a strike in the details of a stoppage, i.e. "Punch to Head At Distance"
+var strikeTarget = regexp.MustCompile(`(?i)\s+(to|at|in|on|from)\s+.*$`)
+
+// the finishing technique of a stoppage from its Fight.MethodDetail: the submission ("Rear Naked Choke")
+// or the strike without its target ("Punch to Head At Distance" -> "Punch"). empty for decisions and
+// everything else, where the detail holds the scorecards or nothing useful
+func TechniqueOf(method, methodDetail string) string {
+	detail := strings.Join(strings.Fields(methodDetail), " ")
+	if detail == "" {
+		return ""
+	}
+
+	switch MethodCategoryOf(method) {
+	case MethodSubmission:
+		return titleCase(detail)
+	case MethodKOTKO:
+		return titleCase(strikeTarget.ReplaceAllString(detail, ""))
+	}
+	return ""
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// fill in Fight.MethodCategory and Fight.Technique on stored fights that were scraped before they
+// existed. returns the number of fields set
+func BackfillMethods(ctx context.Context, db *mongo.Database) (int, error) {
+	coll := db.Collection("fights")
+	updated := 0
+
+	// categories only depend on the method
+	var methods []string
+	if err := coll.Distinct(ctx, "method", bson.M{"method_category": bson.M{"$exists": false}}).Decode(&methods); err != nil {
+		return 0, fmt.Errorf("failed to read fight methods: %v", err)
+	}
+	for _, m := range methods {
+		category := MethodCategoryOf(m)
+		if category == "" {
+			continue
+		}
+		res, err := coll.UpdateMany(ctx,
+			bson.M{"method": m, "method_category": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"method_category": category}},
+		)
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill method categories: %v", err)
+		}
+		updated += int(res.ModifiedCount)
+	}
+
+	// techniques only exist for stoppages, whose details repeat a lot (decisions hold their scorecards)
+	cur, err := coll.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{
+			"method_category": bson.M{"$in": bson.A{MethodKOTKO, MethodSubmission}},
+			"technique":       bson.M{"$exists": false},
+		}},
+		bson.M{"$group": bson.M{"_id": bson.M{"method": "$method", "method_detail": "$method_detail"}}},
+	})
+	if err != nil {
+		return updated, fmt.Errorf("failed to read fight details: %v", err)
+	}
+	var pairs []struct {
+		ID struct {
+			Method       string `bson:"method"`
+			MethodDetail string `bson:"method_detail"`
+		} `bson:"_id"`
+	}
+	if err := cur.All(ctx, &pairs); err != nil {
+		return updated, fmt.Errorf("decode fight details failed: %v", err)
+	}
+	for _, p := range pairs {
+		technique := TechniqueOf(p.ID.Method, p.ID.MethodDetail)
+		if technique == "" {
+			continue
+		}
+		res, err := coll.UpdateMany(ctx,
+			bson.M{"method": p.ID.Method, "method_detail": p.ID.MethodDetail, "technique": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"technique": technique}},
+		)
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill techniques: %v", err)
+		}
+		updated += int(res.ModifiedCount)
+	}
+
+	return updated, nil
+}
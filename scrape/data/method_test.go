@@ -0,0 +1,97 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMethodCategoryOf(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"KO/TKO", MethodKOTKO},
+		{"TKO - Doctor's Stoppage", MethodDoctorStoppage},
+		{"TKO - Doctor's Stoppage ", MethodDoctorStoppage},
+		{"Submission", MethodSubmission},
+		{"SUB", MethodSubmission},
+		{"Decision - Unanimous", MethodDecisionUnanimous},
+		{"U-DEC", MethodDecisionUnanimous},
+		{"Decision - Split", MethodDecisionSplit},
+		{"S-DEC", MethodDecisionSplit},
+		{"Decision - Majority", MethodDecisionMajority},
+		{"M-DEC", MethodDecisionMajority},
+		{"DQ", MethodDisqualification},
+		{"Disqualification", MethodDisqualification},
+		{"Overturned", MethodOverturned},
+		{"Could Not Continue", MethodCouldNotContinue},
+		{"Other", MethodOther},
+		{"", ""},
+		{"   ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MethodCategoryOf(tt.method); got != tt.want {
+			t.Errorf("MethodCategoryOf(%q) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
+
+// IsFinish and the finish categories agree on every spelling, the short ones included
+func TestFinishCategories(t *testing.T) {
+	tests := []struct {
+		method string
+		finish bool
+	}{
+		{"KO/TKO", true},
+		{"TKO - Doctor's Stoppage", true},
+		{"Submission", true},
+		{"SUB", true},
+		{"Decision - Unanimous", false},
+		{"U-DEC", false},
+		{"Decision - Split", false},
+		{"S-DEC", false},
+		{"Decision - Majority", false},
+		{"M-DEC", false},
+		{"DQ", false},
+		{"Overturned", false},
+		{"Could Not Continue", false},
+		{"Other", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		inCategories := slices.Contains(FinishCategories, MethodCategoryOf(tt.method))
+		if got := IsFinish(tt.method); got != tt.finish || inCategories != tt.finish {
+			t.Errorf("%q: IsFinish = %v, in FinishCategories = %v, want %v", tt.method, got, inCategories, tt.finish)
+		}
+	}
+}
+
+func TestTechniqueOf(t *testing.T) {
+	tests := []struct {
+		method string
+		detail string
+		want   string
+	}{
+		{"Submission", "Rear Naked Choke", "Rear Naked Choke"},
+		{"Submission", "  rear   naked choke ", "Rear Naked Choke"},
+		{"Submission", "ARMBAR", "Armbar"},
+		{"KO/TKO", "Punch to Head At Distance", "Punch"},
+		{"KO/TKO", "Punches to Head From Mount", "Punches"},
+		{"KO/TKO", "Kick to Head At Distance", "Kick"},
+		{"KO/TKO", "Knee to Body In Clinch", "Knee"},
+		{"KO/TKO", "Spinning Back Kick to Body At Distance", "Spinning Back Kick"},
+		{"KO/TKO", "Elbows on Ground", "Elbows"},
+		{"TKO - Doctor's Stoppage", "Cut", ""},
+		{"Decision - Unanimous", "Sal D'amato 30 - 27.", ""},
+		{"Submission", "", ""},
+		{"", "Punch", ""},
+	}
+
+	for _, tt := range tests {
+		if got := TechniqueOf(tt.method, tt.detail); got != tt.want {
+			t.Errorf("TechniqueOf(%q, %q) = %q, want %q", tt.method, tt.detail, got, tt.want)
+		}
+	}
+}
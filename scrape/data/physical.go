@@ -0,0 +1,52 @@
+package data
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// inches in a Fighter.Height like 5' 11", false for "--"
+func HeightInches(s string) (float64, bool) {
+	ft, in, ok := strings.Cut(strings.TrimSpace(s), "'")
+	if !ok {
+		return 0, false
+	}
+
+	feet, err := strconv.ParseFloat(strings.TrimSpace(ft), 64)
+	if err != nil {
+		return 0, false
+	}
+	inches, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(in), `"`), 64)
+	if err != nil {
+		inches = 0
+	}
+
+	return feet*12 + inches, true
+}
+
+// inches in a Fighter.ReachIN like 72", false for "--"
+func ReachInches(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(s), `"`), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// fraction in a CareerStats percentage like 45%, false when it does not parse
+func Percent(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v / 100, true
+}
+
+// age in years on the given date, false when the date of birth is unknown
+func AgeOn(dob *time.Time, on time.Time) (float64, bool) {
+	if dob == nil || dob.IsZero() || on.IsZero() {
+		return 0, false
+	}
+	return on.Sub(*dob).Hours() / 24 / 365.25, true
+}
@@ -0,0 +1,27 @@
+package data
+
+import "time"
+
+// a fighter's rating before and after one fight, stored in the 'ratings' collection. the newest snapshot
+// of a fighter is their current rating
+type RatingSnapshot struct {
+	ID          string    `bson:"_id" json:"id"`                                // fight id + ":" + fighter id
+	FighterID   string    `bson:"fighter_id" json:"fighter_id"`                 // id of the rated fighter
+	FighterName string    `bson:"fighter_name" json:"fighter_name"`             // name of the rated fighter
+	OpponentID  string    `bson:"opponent_id" json:"opponent_id"`               // id of the opponent
+	FightID     string    `bson:"fight_id" json:"fight_id"`                     // id of the fight
+	EventID     string    `bson:"event_id" json:"event_id"`                     // id of the event
+	Date        time.Time `bson:"date" json:"date"`                             // date of the event
+	Division    string    `bson:"division,omitempty" json:"division,omitempty"` // division slug of the fight (see DivisionOf)
+	Outcome     string    `bson:"outcome" json:"outcome"`                       // 'W', 'L' or 'D' for the rated fighter
+	Method      string    `bson:"method" json:"method"`                         // winning method of the fight
+	Before      float64   `bson:"before" json:"before"`                         // rating going into the fight
+	After       float64   `bson:"after" json:"after"`                           // rating after the fight
+	Fights      int       `bson:"fights" json:"fights"`                         // rated fights so far, this one included
+	Model       string    `bson:"model" json:"model"`                           // rating model and parameters that produced it
+}
+
+// this will feed the /ratings and /fighters/{id}/ratings endpoints
+type RatingSnapshots struct {
+	Items []RatingSnapshot `bson:"ratings" json:"ratings"`
+}
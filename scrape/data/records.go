@@ -0,0 +1,62 @@
+package data
+
+import "time"
+
+// record lists kept in the 'records' collection, one document each
+const (
+	RecordCurrentWinStreak = "current-win-streak"
+	RecordLongestWinStreak = "longest-win-streak"
+	RecordFastestFinishes  = "fastest-finishes"
+	RecordMostFinishes     = "most-finishes"
+	RecordMostTitleWins    = "most-title-fight-wins"
+)
+
+var RecordTypes = []string{
+	RecordCurrentWinStreak,
+	RecordLongestWinStreak,
+	RecordFastestFinishes,
+	RecordMostFinishes,
+	RecordMostTitleWins,
+}
+
+// one place of a record list
+type RecordEntry struct {
+	Rank        int        `bson:"rank" json:"rank"`
+	FighterID   string     `bson:"fighter_id" json:"fighter_id"`
+	FighterName string     `bson:"fighter_name" json:"fighter_name"`
+	Value       int        `bson:"value" json:"value"`                           // in the unit of the list
+	FightID     string     `bson:"fight_id,omitempty" json:"fight_id,omitempty"` // fastest finishes
+	Opponent    string     `bson:"opponent,omitempty" json:"opponent,omitempty"` // fastest finishes
+	Method      string     `bson:"method,omitempty" json:"method,omitempty"`     // fastest finishes
+	Division    string     `bson:"division,omitempty" json:"division,omitempty"` // fastest finishes
+	From        *time.Time `bson:"from,omitempty" json:"from,omitempty"`         // first fight of a streak, date of a finish
+	To          *time.Time `bson:"to,omitempty" json:"to,omitempty"`             // last fight of a streak
+	Ongoing     bool       `bson:"ongoing,omitempty" json:"ongoing,omitempty"`   // the streak is still running
+}
+
+// a ranked record list
+type RecordList struct {
+	Type      string        `bson:"_id" json:"type"`
+	Unit      string        `bson:"unit" json:"unit"` // "wins" or "seconds"
+	Entries   []RecordEntry `bson:"entries" json:"entries"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// win and loss streaks of a fighter. no contests do not break a streak, draws do
+type Streaks struct {
+	Current        int        `bson:"current" json:"current"`         // +N wins or -N losses in a row
+	CurrentWin     int        `bson:"current_win" json:"current_win"` // 0 unless their last result was a win
+	LongestWin     int        `bson:"longest_win" json:"longest_win"`
+	LongestWinFrom *time.Time `bson:"longest_win_from,omitempty" json:"longest_win_from,omitempty"`
+	LongestWinTo   *time.Time `bson:"longest_win_to,omitempty" json:"longest_win_to,omitempty"`
+}
+
+// a fighter's records from their fights ('fighterRecords' collection, rebuilt after every load)
+type FighterRecord struct {
+	ID             string  `bson:"_id" json:"fighter_id"`
+	Name           string  `bson:"fighter_name" json:"fighter_name"`
+	Streaks        Streaks `bson:"streaks" json:"streaks"`
+	Finishes       int     `bson:"finishes" json:"finishes"`
+	TitleFightWins int     `bson:"title_fight_wins" json:"title_fight_wins"`
+	FastestFinish  int     `bson:"fastest_finish,omitempty" json:"fastest_finish,omitempty"` // seconds
+}
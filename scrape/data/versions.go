@@ -0,0 +1,138 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// a snapshot of a fighter document and the period it was the current one
+type FighterVersion struct {
+	ID        string        `bson:"_id" json:"id"`                                // unique id given to the version
+	FighterID string        `bson:"fighter_id" json:"fighter_id"`                 // id of the fighter
+	ValidFrom time.Time     `bson:"valid_from" json:"valid_from"`                 // load that stored this version (for seeded ones when they were seeded)
+	ValidTo   *time.Time    `bson:"valid_to,omitempty" json:"valid_to,omitempty"` // load that replaced this version, nil for the current one
+	Changes   []FieldChange `bson:"changes,omitempty" json:"changes,omitempty"`   // fields that changed compared to the previous version
+	Fighter   Fighter       `bson:"fighter" json:"fighter"`                       // the fighter as it was stored
+}
+
+// this will feed the /fighters/{id}/history endpoint
+type FighterVersions struct {
+	Items []FighterVersion `bson:"versions" json:"versions"`
+}
+
+// fighters that were loaded before versioning existed get their stored document archived as their first version,
+// valid from the seeding time 'at' since nothing is known about how long it was current before. so the state
+// before their next change is not lost. run before loading the fighters
+func SeedFighterVersions(ctx context.Context, db *mongo.Database, ids []string, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var versioned []string
+	if err := db.Collection("fighterVersions").Distinct(ctx, "fighter_id", bson.M{"fighter_id": bson.M{"$in": ids}}).Decode(&versioned); err != nil {
+		return fmt.Errorf("failed to read fighter versions: %v", err)
+	}
+
+	seen := make(map[string]bool, len(versioned))
+	for _, id := range versioned {
+		seen[id] = true
+	}
+
+	missing := make([]string, 0)
+	for _, id := range ids {
+		if !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	cur, err := db.Collection("fighters").Find(ctx, bson.M{"_id": bson.M{"$in": missing}})
+	if err != nil {
+		return fmt.Errorf("failed to read fighters: %v", err)
+	}
+	defer cur.Close(ctx)
+
+	seeds := make([]any, 0)
+	for cur.Next(ctx) {
+		var f Fighter
+		if err := cur.Decode(&f); err != nil {
+			return fmt.Errorf("decode fighter failed: %v", err)
+		}
+		seeds = append(seeds, FighterVersion{ID: bson.NewObjectID().Hex(), FighterID: f.ID, ValidFrom: at, Fighter: f})
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+
+	if len(seeds) == 0 {
+		return nil
+	}
+
+	if _, err := db.Collection("fighterVersions").InsertMany(ctx, seeds); err != nil {
+		return fmt.Errorf("failed to seed fighter versions: %v", err)
+	}
+	fmt.Printf("[Seeded %d fighter versions]\n", len(seeds))
+
+	return nil
+}
+
+// archive a new version for every fighter the load added or updated and close the version it replaces. run after loading the fighters
+func ArchiveFighterVersions(ctx context.Context, db *mongo.Database, fighters FighterMap, loaded *LoadSummary, at time.Time) error {
+	changes := make(map[string][]FieldChange, len(loaded.Added)+len(loaded.Updated))
+	for _, id := range loaded.Added {
+		changes[id] = nil
+	}
+	for _, u := range loaded.Updated {
+		changes[u.ID] = u.Fields
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
+	}
+
+	coll := db.Collection("fighterVersions")
+
+	if _, err := coll.UpdateMany(ctx,
+		bson.M{"fighter_id": bson.M{"$in": ids}, "valid_to": nil},
+		bson.M{"$set": bson.M{"valid_to": at}},
+	); err != nil {
+		return fmt.Errorf("failed to close fighter versions: %v", err)
+	}
+
+	versions := make([]mongo.WriteModel, 0, len(ids))
+	for _, id := range ids {
+		f, ok := fighters[id]
+		if !ok {
+			continue
+		}
+		versions = append(versions, mongo.NewInsertOneModel().SetDocument(FighterVersion{
+			ID:        bson.NewObjectID().Hex(),
+			FighterID: id,
+			ValidFrom: at,
+			Changes:   changes[id],
+			Fighter:   *f,
+		}))
+	}
+
+	if len(versions) == 0 {
+		return nil
+	}
+
+	if _, err := coll.BulkWrite(ctx, versions, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to archive fighter versions: %v", err)
+	}
+	fmt.Printf("✅ [%d fighter versions archived]\n", len(versions))
+
+	return nil
+}
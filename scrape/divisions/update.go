@@ -0,0 +1,156 @@
+package divisions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	writeBatchSize = 1000
+
+	// fights looked at for a fighter's primary division
+	recentFights = 5
+)
+
+// fill in Fight.Division on stored fights that were scraped before it existed. returns the fights updated
+func Backfill(ctx context.Context, db *mongo.Database) (int, error) {
+	coll := db.Collection("fights")
+	missing := bson.M{"division": bson.M{"$exists": false}}
+
+	var details []string
+	if err := coll.Distinct(ctx, "fight_detail", missing).Decode(&details); err != nil {
+		return 0, fmt.Errorf("failed to read fight details: %v", err)
+	}
+
+	updated := 0
+	for _, detail := range details {
+		division := data.DivisionOf(detail)
+		if division == "" {
+			continue
+		}
+
+		res, err := coll.UpdateMany(ctx,
+			bson.M{"fight_detail": detail, "division": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"division": division}},
+		)
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill divisions: %v", err)
+		}
+		updated += int(res.ModifiedCount)
+	}
+
+	return updated, nil
+}
+
+// primary and current division of every fighter from their fights, which must be in date order
+func Assign(history []data.DatedFight) map[string]*data.FighterDivision {
+	fighters := make(map[string]*data.FighterDivision)
+	recent := make(map[string][]string) // newest last, ranked divisions only
+
+	for _, f := range history {
+		division := data.DivisionOf(f.FightDetail)
+		d, _ := data.DivisionBySlug(division)
+
+		for _, p := range f.Participants {
+			fd, ok := fighters[p.FighterID]
+			if !ok {
+				fd = &data.FighterDivision{ID: p.FighterID, Fights: map[string]int{}}
+				fighters[p.FighterID] = fd
+			}
+			fd.Name = p.FighterName
+			fd.LastFight = f.EventDate
+			if division == "" {
+				continue
+			}
+			fd.Fights[division]++
+
+			// catch and open weight bouts say nothing about where a fighter competes
+			if !d.Ranked {
+				continue
+			}
+			fd.Current = division
+			recent[p.FighterID] = append(recent[p.FighterID], division)
+		}
+	}
+
+	for id, fd := range fighters {
+		fd.Primary = primary(recent[id])
+	}
+
+	return fighters
+}
+
+// the division with the most of the last recentFights fights, the newer one on a tie
+func primary(divisions []string) string {
+	if len(divisions) > recentFights {
+		divisions = divisions[len(divisions)-recentFights:]
+	}
+
+	counts := make(map[string]int)
+	best := ""
+	for _, d := range divisions {
+		counts[d]++
+		if counts[d] >= counts[best] {
+			best = d
+		}
+	}
+	return best
+}
+
+// backfill fight divisions and rebuild the 'fighterDivisions' collection. returns the fighters written
+func Update(ctx context.Context, db *mongo.Database) (int, error) {
+	backfilled, err := Backfill(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if backfilled > 0 {
+		fmt.Printf("✅ [Divisions backfilled on %d fights]\n", backfilled)
+	}
+
+	history, err := data.LoadFightHistory(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	coll := db.Collection("fighterDivisions")
+
+	ids := make([]string, 0)
+	batch := make([]mongo.WriteModel, 0, writeBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("failed to write fighter divisions: %v", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for id, fd := range Assign(history) {
+		ids = append(ids, id)
+		batch = append(batch, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": id}).SetReplacement(fd).SetUpsert(true))
+		if len(batch) >= writeBatchSize {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+
+	// fighters whose fights are all gone
+	if _, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$nin": ids}}); err != nil {
+		return len(ids), fmt.Errorf("failed to clear fighter divisions: %v", err)
+	}
+
+	fmt.Printf("✅ [Divisions assigned to %d fighters]\n", len(ids))
+
+	return len(ids), nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/anthonybliss1/ufc-api/scrape/features"
+	"github.com/anthonybliss1/ufc-api/scrape/utils"
+)
+
+// 'scrape features' writes the pre-fight feature matrix of every stored fight as csv. every row only
+// uses fights from earlier dates, never the current profile stats
+func runFeatures(args []string) {
+	fs := flag.NewFlagSet("features", flag.ExitOnError)
+
+	out := fs.String("out", "features.csv", "path of the csv file to write, - for stdout")
+
+	fs.Parse(args)
+
+	ctx := context.Background()
+
+	mc, err := utils.ConnectMongo(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mc.Disconnect(ctx)
+
+	fmt.Fprintln(os.Stderr, "[Building Feature Matrix...]")
+
+	rows, err := features.Load(ctx, mc.Database("ufc"))
+	if err != nil {
+		log.Fatalf("[Feature Matrix Failed: %v]", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("[Failed to create %s: %v]", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := features.WriteCSV(w, rows); err != nil {
+		log.Fatalf("[Failed to write features: %v]", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ [%d fights written to %s]\n", len(rows), *out)
+}
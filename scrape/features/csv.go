@@ -0,0 +1,94 @@
+package features
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+)
+
+func formatInt(v int) string {
+	return strconv.Itoa(v)
+}
+
+// NaN is written as an empty cell
+func formatFloat(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// the per fighter columns, written once with an a_ prefix and once with b_
+var snapshotColumns = []struct {
+	name  string
+	value func(Snapshot) string
+}{
+	{"id", func(s Snapshot) string { return s.FighterID }},
+	{"name", func(s Snapshot) string { return s.Name }},
+	{"ufc_fights", func(s Snapshot) string { return formatInt(s.Fights) }},
+	{"wins", func(s Snapshot) string { return formatInt(s.Wins) }},
+	{"losses", func(s Snapshot) string { return formatInt(s.Losses) }},
+	{"draws", func(s Snapshot) string { return formatInt(s.Draws) }},
+	{"finishes", func(s Snapshot) string { return formatInt(s.Finishes) }},
+	{"streak", func(s Snapshot) string { return formatInt(s.Streak) }},
+	{"layoff_days", func(s Snapshot) string { return formatFloat(s.LayoffDays) }},
+	{"age", func(s Snapshot) string { return formatFloat(s.Age) }},
+	{"slpm", func(s Snapshot) string { return formatFloat(s.SLpM) }},
+	{"sapm", func(s Snapshot) string { return formatFloat(s.SApM) }},
+	{"str_acc", func(s Snapshot) string { return formatFloat(s.StrAcc) }},
+	{"str_def", func(s Snapshot) string { return formatFloat(s.StrDef) }},
+	{"td_avg", func(s Snapshot) string { return formatFloat(s.TdAvg) }},
+	{"td_acc", func(s Snapshot) string { return formatFloat(s.TdAcc) }},
+	{"td_def", func(s Snapshot) string { return formatFloat(s.TdDef) }},
+	{"sub_avg", func(s Snapshot) string { return formatFloat(s.SubAvg) }},
+	{"kd_avg", func(s Snapshot) string { return formatFloat(s.KdAvg) }},
+	{"ctrl_share", func(s Snapshot) string { return formatFloat(s.CtrlShare) }},
+}
+
+func header() []string {
+	h := []string{"fight_id", "event_id", "date", "division"}
+	for _, prefix := range []string{"a_", "b_"} {
+		for _, c := range snapshotColumns {
+			h = append(h, prefix+c.name)
+		}
+	}
+	return append(h, "outcome", "method", "a_win")
+}
+
+func csvRecord(row Row) []string {
+	rec := []string{row.FightID, row.EventID, row.Date.Format("2006-01-02"), row.Division}
+	for _, s := range []Snapshot{row.A, row.B} {
+		for _, c := range snapshotColumns {
+			rec = append(rec, c.value(s))
+		}
+	}
+
+	// the label, empty for draws and no contests
+	win := ""
+	switch row.Outcome {
+	case "W":
+		win = "1"
+	case "L":
+		win = "0"
+	}
+
+	return append(rec, row.Outcome, row.Method, win)
+}
+
+// write the rows as csv with a header line
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(header()); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := cw.Write(csvRecord(row)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
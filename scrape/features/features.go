@@ -0,0 +1,287 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// running totals of one fighter's UFC career, only ever fed fights that already happened
+type record struct {
+	fights, wins, losses, draws int
+	finishes                    int
+	streak                      int // +N on a win streak, -N on a losing streak
+	last                        time.Time
+
+	seconds         int // time spent fighting
+	sigLanded       int
+	sigAttempted    int
+	sigAbsorbed     int // opponents' significant strikes landed
+	oppSigAttempted int
+	tdLanded        int
+	tdAttempted     int
+	oppTdLanded     int
+	oppTdAttempted  int
+	ctrlSeconds     int
+	knockdowns      int
+	subAttempts     int
+}
+
+// one fighter going into a fight. rates are NaN until the fighter has a fight (or fight time) to
+// compute them from, age and layoff are NaN when unknown
+type Snapshot struct {
+	FighterID  string
+	Name       string
+	Fights     int // UFC fights before this one
+	Wins       int
+	Losses     int
+	Draws      int
+	Finishes   int     // wins by KO/TKO or submission
+	Streak     int     // +N on a win streak, -N on a losing streak
+	LayoffDays float64 // days since the previous UFC fight
+	Age        float64 // years on fight night
+	SLpM       float64 // significant strikes landed per minute
+	SApM       float64 // significant strikes absorbed per minute
+	StrAcc     float64 // significant strike accuracy
+	StrDef     float64 // share of opponents' significant strikes that missed
+	TdAvg      float64 // takedowns landed per 15 minutes
+	TdAcc      float64 // takedown accuracy
+	TdDef      float64 // share of opponents' takedowns that failed
+	SubAvg     float64 // submission attempts per 15 minutes
+	KdAvg      float64 // knockdowns per 15 minutes
+	CtrlShare  float64 // share of fight time spent in control
+}
+
+// one row of the feature matrix: both fighters as they were before the fight and the result
+type Row struct {
+	FightID  string
+	EventID  string
+	Date     time.Time
+	Division string
+	A, B     Snapshot
+	Outcome  string // A's outcome: W, L, D or NC
+	Method   string
+}
+
+// replays fights in date order and hands out each fighter's numbers as of before a fight
+type Tracker struct {
+	records map[string]*record
+	dobs    map[string]*time.Time
+}
+
+// dobs maps fighter ids to dates of birth (the only profile value used, it does not change over time)
+func NewTracker(dobs map[string]*time.Time) *Tracker {
+	return &Tracker{records: make(map[string]*record), dobs: dobs}
+}
+
+func ratio(num, den float64) float64 {
+	if den == 0 {
+		return math.NaN()
+	}
+	return num / den
+}
+
+// the fighter's numbers before a fight on the given date
+func (t *Tracker) Before(fighterID, name string, on time.Time) Snapshot {
+	s := Snapshot{FighterID: fighterID, Name: name, LayoffDays: math.NaN(), Age: math.NaN()}
+	if age, ok := data.AgeOn(t.dobs[fighterID], on); ok {
+		s.Age = age
+	}
+
+	rec, ok := t.records[fighterID]
+	if !ok {
+		rec = &record{}
+	}
+	if !rec.last.IsZero() {
+		s.LayoffDays = on.Sub(rec.last).Hours() / 24
+	}
+
+	mins := float64(rec.seconds) / 60
+
+	s.Fights, s.Wins, s.Losses, s.Draws = rec.fights, rec.wins, rec.losses, rec.draws
+	s.Finishes, s.Streak = rec.finishes, rec.streak
+	s.SLpM = ratio(float64(rec.sigLanded), mins)
+	s.SApM = ratio(float64(rec.sigAbsorbed), mins)
+	s.StrAcc = ratio(float64(rec.sigLanded), float64(rec.sigAttempted))
+	s.StrDef = 1 - ratio(float64(rec.sigAbsorbed), float64(rec.oppSigAttempted))
+	s.TdAvg = ratio(float64(rec.tdLanded)*15, mins)
+	s.TdAcc = ratio(float64(rec.tdLanded), float64(rec.tdAttempted))
+	s.TdDef = 1 - ratio(float64(rec.oppTdLanded), float64(rec.oppTdAttempted))
+	s.SubAvg = ratio(float64(rec.subAttempts)*15, mins)
+	s.KdAvg = ratio(float64(rec.knockdowns)*15, mins)
+	s.CtrlShare = ratio(float64(rec.ctrlSeconds), float64(rec.seconds))
+
+	return s
+}
+
+// add a finished fight to both fighters' totals
+func (t *Tracker) Apply(f data.DatedFight) {
+	// round lengths of the time format, early events had 10 and 15 minute rounds
+	seconds, _ := data.ElapsedSeconds(f.Round, f.EndTime, f.TimeFormat)
+
+	for i, me := range f.Participants {
+		rec, ok := t.records[me.FighterID]
+		if !ok {
+			rec = &record{}
+			t.records[me.FighterID] = rec
+		}
+
+		rec.fights++
+		rec.last = f.EventDate
+		rec.seconds += seconds
+		rec.sigLanded += me.SigStrL
+		rec.sigAttempted += me.SigStrA
+		rec.tdLanded += me.TdL
+		rec.tdAttempted += me.TdA
+		rec.ctrlSeconds += data.ClockSeconds(me.Ctrl)
+		rec.knockdowns += me.KD
+		rec.subAttempts += me.Sub
+
+		for j, opp := range f.Participants {
+			if j == i {
+				continue
+			}
+			rec.sigAbsorbed += opp.SigStrL
+			rec.oppSigAttempted += opp.SigStrA
+			rec.oppTdLanded += opp.TdL
+			rec.oppTdAttempted += opp.TdA
+		}
+
+		switch me.Outcome {
+		case "W":
+			rec.wins++
+			if data.IsFinish(f.Method) {
+				rec.finishes++
+			}
+			rec.streak = max(rec.streak, 0) + 1
+		case "L":
+			rec.losses++
+			rec.streak = min(rec.streak, 0) - 1
+		case "D":
+			rec.draws++
+			rec.streak = 0
+		}
+		// no contests leave the streak alone
+	}
+}
+
+// the feature row of a two-fighter bout from the numbers before it, call before applying its date
+func (t *Tracker) Row(f data.DatedFight) (Row, bool) {
+	if len(f.Participants) != 2 {
+		return Row{}, false
+	}
+	a, b := f.Participants[0], f.Participants[1]
+
+	outcome := a.Outcome
+	if outcome == "" {
+		outcome = "NC"
+	}
+
+	return Row{
+		FightID:  f.ID,
+		EventID:  f.EventID,
+		Date:     f.EventDate,
+		Division: data.DivisionOf(f.FightDetail),
+		A:        t.Before(a.FighterID, a.FighterName, f.EventDate),
+		B:        t.Before(b.FighterID, b.FighterName, f.EventDate),
+		Outcome:  outcome,
+		Method:   f.Method,
+	}, true
+}
+
+// a two-fighter bout with its pre-fight row
+type Bout struct {
+	Row   Row
+	Fight data.DatedFight
+}
+
+// call fn with each run of items sharing a date (unix seconds)
+func EachDate[T any](items []T, date func(T) int64, fn func([]T)) {
+	for start := 0; start < len(items); {
+		end := start
+		for end < len(items) && date(items[end]) == date(items[start]) {
+			end++
+		}
+		fn(items[start:end])
+		start = end
+	}
+}
+
+// every two-fighter bout in the history (which must be in date order), each row built only from
+// fights on earlier dates. a whole date is applied at once since the order of bouts on one card is
+// unknown (early tournaments had fighters on the card more than once). everything that needs
+// leakage free numbers goes through here
+func Bouts(history []data.DatedFight, dobs map[string]*time.Time) []Bout {
+	t := NewTracker(dobs)
+	bouts := make([]Bout, 0, len(history))
+
+	EachDate(history, func(f data.DatedFight) int64 { return f.EventDate.Unix() }, func(day []data.DatedFight) {
+		for _, f := range day {
+			if row, ok := t.Row(f); ok {
+				bouts = append(bouts, Bout{Row: row, Fight: f})
+			}
+		}
+		for _, f := range day {
+			t.Apply(f)
+		}
+	})
+
+	return bouts
+}
+
+// a tracker with every fight of the history (in date order) applied, for the numbers going into
+// fights that have not happened yet
+func Replay(history []data.DatedFight, dobs map[string]*time.Time) *Tracker {
+	t := NewTracker(dobs)
+	for _, f := range history {
+		t.Apply(f)
+	}
+	return t
+}
+
+// the rows of Bouts
+func Build(history []data.DatedFight, dobs map[string]*time.Time) []Row {
+	bouts := Bouts(history, dobs)
+	rows := make([]Row, len(bouts))
+	for i, b := range bouts {
+		rows[i] = b.Row
+	}
+	return rows
+}
+
+// every fighter's date of birth by id
+func LoadDOBs(ctx context.Context, db *mongo.Database) (map[string]*time.Time, error) {
+	cur, err := db.Collection("fighters").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"dob": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fighters: %v", err)
+	}
+	var fighters []data.Fighter
+	if err := cur.All(ctx, &fighters); err != nil {
+		return nil, fmt.Errorf("decode fighters failed: %v", err)
+	}
+
+	dobs := make(map[string]*time.Time, len(fighters))
+	for _, f := range fighters {
+		dobs[f.ID] = f.DOB
+	}
+	return dobs, nil
+}
+
+// the feature matrix of every stored fight
+func Load(ctx context.Context, db *mongo.Database) ([]Row, error) {
+	history, err := data.LoadFightHistory(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	dobs, err := LoadDOBs(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return Build(history, dobs), nil
+}
@@ -0,0 +1,241 @@
+package features
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+)
+
+func day(d int) time.Time {
+	return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+// a 3 x 5 minute bout won by 'winner' in round 1 at 5:00 unless round/end are set
+func fight(id string, on time.Time, method, winner, loser string) data.DatedFight {
+	return data.DatedFight{
+		Fight: data.Fight{
+			ID:         id,
+			Method:     method,
+			Round:      1,
+			EndTime:    "5:00",
+			TimeFormat: "3 Rnd (5-5-5)",
+			Participants: []data.FightStats{
+				{FighterID: winner, FighterName: winner, Outcome: "W", SigStrL: 20, SigStrA: 40, TdL: 1, TdA: 2, Ctrl: "1:00", KD: 1},
+				{FighterID: loser, FighterName: loser, Outcome: "L", SigStrL: 10, SigStrA: 30, TdL: 0, TdA: 3, Sub: 1},
+			},
+		},
+		EventDate: on,
+	}
+}
+
+func draw(id string, on time.Time, a, b string) data.DatedFight {
+	f := fight(id, on, "Decision - Split", a, b)
+	f.Participants[0].Outcome, f.Participants[1].Outcome = "D", "D"
+	return f
+}
+
+func TestBefore(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []data.DatedFight
+		on      time.Time
+		want    Snapshot
+	}{
+		{
+			name: "debut",
+			on:   day(1),
+			want: Snapshot{FighterID: "a", Name: "a"},
+		},
+		{
+			name:    "one win",
+			history: []data.DatedFight{fight("1", day(1), "KO/TKO", "a", "b")},
+			on:      day(11),
+			want: Snapshot{FighterID: "a", Name: "a", Fights: 1, Wins: 1, Finishes: 1, Streak: 1, LayoffDays: 10,
+				SLpM: 4, SApM: 2, StrAcc: 0.5, StrDef: 1 - 10.0/30, TdAvg: 3, TdAcc: 0.5, TdDef: 1, SubAvg: 0, KdAvg: 3, CtrlShare: 0.2},
+		},
+		{
+			name: "decision win after two losses",
+			history: []data.DatedFight{
+				fight("1", day(1), "Submission", "b", "a"),
+				fight("2", day(2), "KO/TKO", "c", "a"),
+				fight("3", day(3), "Decision - Unanimous", "a", "b"),
+			},
+			on: day(3),
+			want: Snapshot{FighterID: "a", Name: "a", Fights: 3, Wins: 1, Losses: 2, Streak: 1, LayoffDays: 0,
+				SLpM: 40.0 / 15, SApM: 50.0 / 15, StrAcc: 40.0 / 100, StrDef: 1 - 50.0/110, TdAvg: 1, TdAcc: 1.0 / 8, TdDef: 1 - 2.0/7, SubAvg: 2, KdAvg: 1, CtrlShare: 60.0 / 900},
+		},
+		{
+			name:    "losing streak",
+			history: []data.DatedFight{fight("1", day(1), "KO/TKO", "b", "a"), fight("2", day(2), "KO/TKO", "c", "a")},
+			on:      day(3),
+			want: Snapshot{FighterID: "a", Name: "a", Fights: 2, Losses: 2, Streak: -2, LayoffDays: 1,
+				SLpM: 2, SApM: 4, StrAcc: 1.0 / 3, StrDef: 0.5, TdAvg: 0, TdAcc: 0, TdDef: 0.5, SubAvg: 3, KdAvg: 0, CtrlShare: 0},
+		},
+		{
+			name:    "draw resets the streak",
+			history: []data.DatedFight{fight("1", day(1), "KO/TKO", "a", "b"), draw("2", day(2), "a", "b")},
+			on:      day(2),
+			want: Snapshot{FighterID: "a", Name: "a", Fights: 2, Wins: 1, Draws: 1, Finishes: 1, Streak: 0, LayoffDays: 0,
+				SLpM: 4, SApM: 2, StrAcc: 0.5, StrDef: 1 - 20.0/60, TdAvg: 3, TdAcc: 0.5, TdDef: 1, SubAvg: 0, KdAvg: 3, CtrlShare: 0.2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Replay(tt.history, nil).Before("a", "a", tt.on)
+
+			// an empty record has no rates and no layoff, every case has an unknown age
+			want := tt.want
+			if want.Fights == 0 {
+				nan := math.NaN()
+				want.LayoffDays, want.SLpM, want.SApM, want.StrAcc, want.StrDef = nan, nan, nan, nan, nan
+				want.TdAvg, want.TdAcc, want.TdDef, want.SubAvg, want.KdAvg, want.CtrlShare = nan, nan, nan, nan, nan, nan
+			}
+			want.Age = math.NaN()
+
+			if !snapshotsEqual(got, want) {
+				t.Errorf("Before() =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func snapshotsEqual(a, b Snapshot) bool {
+	if a.FighterID != b.FighterID || a.Name != b.Name || a.Fights != b.Fights || a.Wins != b.Wins ||
+		a.Losses != b.Losses || a.Draws != b.Draws || a.Finishes != b.Finishes || a.Streak != b.Streak {
+		return false
+	}
+	floats := [][2]float64{
+		{a.LayoffDays, b.LayoffDays}, {a.Age, b.Age}, {a.SLpM, b.SLpM}, {a.SApM, b.SApM}, {a.StrAcc, b.StrAcc},
+		{a.StrDef, b.StrDef}, {a.TdAvg, b.TdAvg}, {a.TdAcc, b.TdAcc}, {a.TdDef, b.TdDef}, {a.SubAvg, b.SubAvg},
+		{a.KdAvg, b.KdAvg}, {a.CtrlShare, b.CtrlShare},
+	}
+	for _, f := range floats {
+		if math.IsNaN(f[0]) != math.IsNaN(f[1]) || (!math.IsNaN(f[0]) && math.Abs(f[0]-f[1]) > 1e-9) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestApplyFightTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		round      int
+		endTime    string
+		timeFormat string
+		minutes    float64
+	}{
+		{"five minute rounds", 3, "2:30", "3 Rnd (5-5-5)", 12.5},
+		{"overtime", 2, "1:30", "1 Rnd + 2OT (15-3-3)", 16.5},
+		{"second overtime", 3, "3:00", "1 Rnd + 2OT (15-3-3)", 21},
+		{"no time limit", 1, "9:20", "No Time Limit", 9 + 20.0/60},
+		{"no end time", 2, "--", "3 Rnd (5-5-5)", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := fight("1", day(1), "KO/TKO", "a", "b")
+			f.Round, f.EndTime, f.TimeFormat = tt.round, tt.endTime, tt.timeFormat
+
+			tr := NewTracker(nil)
+			tr.Apply(f)
+			s := tr.Before("a", "a", day(2))
+
+			// 20 significant strikes landed
+			if tt.minutes == 0 {
+				if !math.IsNaN(s.SLpM) {
+					t.Errorf("SLpM = %v, want NaN without fight time", s.SLpM)
+				}
+				return
+			}
+			if want := 20 / tt.minutes; math.Abs(s.SLpM-want) > 1e-9 {
+				t.Errorf("SLpM = %v, want %v", s.SLpM, want)
+			}
+		})
+	}
+}
+
+func TestBeforeAge(t *testing.T) {
+	dob := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := NewTracker(map[string]*time.Time{"a": &dob})
+
+	if age := tr.Before("a", "a", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)).Age; math.Abs(age-30) > 0.01 {
+		t.Errorf("age = %v, want 30", age)
+	}
+	if age := tr.Before("b", "b", day(1)).Age; !math.IsNaN(age) {
+		t.Errorf("age without a dob = %v, want NaN", age)
+	}
+}
+
+// early tournaments put a fighter on the same card more than once, none of the bouts of a date may see
+// another one of that date
+func TestBoutsSameDate(t *testing.T) {
+	history := []data.DatedFight{
+		fight("1", day(1), "Submission", "a", "b"),
+		fight("2", day(1), "KO/TKO", "c", "d"),
+		fight("3", day(1), "Submission", "a", "c"), // tournament final
+		fight("4", day(8), "KO/TKO", "a", "e"),
+	}
+
+	tests := []struct {
+		fightID        string
+		fightsA, winsA int
+		fightsB, winsB int
+	}{
+		{"1", 0, 0, 0, 0},
+		{"2", 0, 0, 0, 0},
+		{"3", 0, 0, 0, 0},
+		{"4", 2, 2, 0, 0},
+	}
+
+	bouts := Bouts(history, nil)
+	if len(bouts) != len(tests) {
+		t.Fatalf("got %d bouts, want %d", len(bouts), len(tests))
+	}
+
+	for i, tt := range tests {
+		row := bouts[i].Row
+		if row.FightID != tt.fightID {
+			t.Fatalf("bout %d is fight %s, want %s", i, row.FightID, tt.fightID)
+		}
+		if row.A.Fights != tt.fightsA || row.A.Wins != tt.winsA || row.B.Fights != tt.fightsB || row.B.Wins != tt.winsB {
+			t.Errorf("fight %s: A %d fights %d wins, B %d fights %d wins, want %d/%d and %d/%d", tt.fightID,
+				row.A.Fights, row.A.Wins, row.B.Fights, row.B.Wins, tt.fightsA, tt.winsA, tt.fightsB, tt.winsB)
+		}
+	}
+
+	if layoff := bouts[3].Row.A.LayoffDays; layoff != 7 {
+		t.Errorf("layoff after the tournament = %v days, want 7", layoff)
+	}
+}
+
+func TestEachDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		dates []int64
+		want  [][]int64
+	}{
+		{"empty", nil, nil},
+		{"one date", []int64{1, 1, 1}, [][]int64{{1, 1, 1}}},
+		{"runs", []int64{1, 2, 2, 3}, [][]int64{{1}, {2, 2}, {3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]int64
+			EachDate(tt.dates, func(d int64) int64 { return d }, func(run []int64) { got = append(got, run) })
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if len(got[i]) != len(tt.want[i]) || got[i][0] != tt.want[i][0] {
+					t.Errorf("run %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
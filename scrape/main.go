@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
 	_ "embed"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/anthonybliss1/ufc-api/scrape/divisions"
+	"github.com/anthonybliss1/ufc-api/scrape/ratings"
+	"github.com/anthonybliss1/ufc-api/scrape/records"
+	"github.com/anthonybliss1/ufc-api/scrape/scheduler"
 	"github.com/anthonybliss1/ufc-api/scrape/utils"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 //go:embed .env
@@ -31,8 +38,33 @@ func init() {
 }
 
 func main() {
+	// 'scrape serve' runs the scraper as a daemon on a schedule instead of a single run
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	// 'scrape train' fits the win probability model from the stored fights
+	if len(os.Args) > 1 && os.Args[1] == "train" {
+		runTrain(os.Args[2:])
+		return
+	}
+	// 'scrape features' exports the pre-fight feature matrix for modeling
+	if len(os.Args) > 1 && os.Args[1] == "features" {
+		runFeatures(os.Args[2:])
+		return
+	}
+	// 'scrape backtest' scores the prediction models on a chronological replay of past fights
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		runBacktest(os.Args[2:])
+		return
+	}
+
 	var update = flag.Bool("update", false, "run update function only")
 	var upcoming = flag.Bool("upcoming", false, "collect upcoming events and matchups")
+	var tx = flag.Bool("tx", false, "load fighters, events and fights in one transaction (replica sets only, chunked otherwise)")
+	var summaryPath = flag.String("summary", "", "write the json load summary to this file")
+	var finishWeight = flag.Float64("finish-weight", 0, "weight KO/TKO and submission results this much more in the ratings (0 ignores the method)")
+	var rebuildRatings = flag.Bool("rebuild-ratings", false, "replay every fight into the ratings instead of only the new ones")
 
 	flag.Parse()
 
@@ -44,47 +76,149 @@ func main() {
 	// start a timer to track the scraping process speed
 	start := time.Now()
 
-	switch true {
-	case *update:
-		// only collect most recent data not in db
-		fmt.Println("[Starting Update...]")
-		fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
-
-		if err := utils.RunUpdate(client); err != nil {
-			log.Panic(err)
+	// hold the same lock as 'scrape serve' so a manual run never overlaps a scheduled one
+	if err := withScrapeLock(func(ctx context.Context, db *mongo.Database) error {
+		switch true {
+		case *update:
+			// only collect most recent data not in db
+			fmt.Println("[Starting Update...]")
+			fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
+
+			if err := collectUpdate(ctx, client); err != nil {
+				return err
+			}
+		case *upcoming:
+			fmt.Println("[Starting Upcoming Collection...]")
+			fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
+
+			if err := collectUpcoming(ctx, client); err != nil {
+				return err
+			}
+		default:
+			// collect all data
+			fmt.Println("[Starting Complete Refresh...]")
+			fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
+
+			if err := collectAll(ctx, client); err != nil {
+				return err
+			}
 		}
-	case *upcoming:
-		fmt.Println("[Starting Upcoming Collection...]")
-		fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
-		upcomingEvent := data.Event{}
 
-		if err := utils.IterateUpcomingEvents(&upcomingEvent, client); err != nil {
-			log.Panic(err)
-		}
-	default:
-		// collect all data
-		fmt.Println("[Starting Complete Refresh...]")
+		// after all data is collected load batches into the mongodb
+		fmt.Println("[Running Batches...]")
 		fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
 
-		if err := utils.IterateFighters(client); err != nil {
-			log.Panic(err)
+		summary, err := utils.RunBatches(ctx, utils.BatchOptions{Transaction: *tx})
+		if rerr := utils.ReportSummary(summary, *summaryPath); rerr != nil {
+			log.Printf("failed to write load summary: %v", rerr)
 		}
 
-		upcomingEvent := data.Event{}
-
-		if err := utils.IterateUpcomingEvents(&upcomingEvent, client); err != nil {
-			log.Panic(err)
-		}
+		afterLoad(ctx, db, summary, loadOptions{
+			Ratings: ratingsOptions(*finishWeight, *rebuildRatings),
+		})
+		return err
+	}); err != nil {
+		log.Fatal(err)
 	}
 
-	// after all data is collected load batches into the mongodb
-	fmt.Println("[Running Batches...]")
-	fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
-	utils.RunBatches()
-
 	// measure time elapsed from the 'start' timestamp
 	elapsed := time.Since(start)
 
 	fmt.Println("\n[Process Completed!]")
 	fmt.Printf("[Time: %.2fH]\n", elapsed.Hours())
 }
+
+// COLLECTION MODES
+// ~~~~~~~~~~~~~~~~~
+// shared by the one off flags and the scheduled jobs of 'scrape serve'
+
+func collectUpdate(ctx context.Context, client *http.Client) error {
+	return utils.RunUpdate(ctx, client)
+}
+
+func collectUpcoming(ctx context.Context, client *http.Client) error {
+	upcomingEvent := data.Event{}
+	return utils.IterateUpcomingEvents(ctx, &upcomingEvent, client)
+}
+
+func collectAll(ctx context.Context, client *http.Client) error {
+	if err := utils.IterateFighters(ctx, client); err != nil {
+		return err
+	}
+	return collectUpcoming(ctx, client)
+}
+
+// run fn while holding the scrape lock in mongo
+func withScrapeLock(fn func(ctx context.Context, db *mongo.Database) error) error {
+	ctx := context.Background()
+
+	mc, err := utils.ConnectMongo(ctx)
+	if err != nil {
+		return err
+	}
+	defer mc.Disconnect(ctx)
+
+	db := mc.Database("ufc")
+	lock := scheduler.NewLock(db, scrapeLockName, scrapeLockTTL)
+	return lock.Do(ctx, func(ctx context.Context) error {
+		return fn(ctx, db)
+	})
+}
+
+// DERIVED DATA
+// ~~~~~~~~~~~~~
+// collections computed from the loaded fights, refreshed after every load
+
+type loadOptions struct {
+	Ratings ratings.Options
+}
+
+func ratingsOptions(finishWeight float64, rebuild bool) ratings.Options {
+	opts := ratings.DefaultOptions()
+	opts.FinishWeight = finishWeight
+	opts.Rebuild = rebuild
+	return opts
+}
+
+// refresh the derived collections, then mark them ready so the api refreshes its caches. a rolled back load
+// changed nothing so there is nothing to refresh, failures are logged and do not fail the run since the
+// loaded data is already committed
+func afterLoad(ctx context.Context, db *mongo.Database, summary *data.RunSummary, opts loadOptions) {
+	if summary == nil || summary.RolledBack {
+		return
+	}
+
+	fmt.Println("[Backfilling Fight Dates...]")
+	if n, err := data.BackfillFightDates(ctx, db); err != nil {
+		log.Printf("failed to backfill fight dates: %v", err)
+	} else if n > 0 {
+		fmt.Printf("✅ [Dates backfilled on %d fights]\n", n)
+	}
+
+	fmt.Println("[Backfilling Fight Methods...]")
+	if n, err := data.BackfillMethods(ctx, db); err != nil {
+		log.Printf("failed to backfill fight methods: %v", err)
+	} else if n > 0 {
+		fmt.Printf("✅ [Methods backfilled on %d fights]\n", n)
+	}
+
+	fmt.Println("[Updating Ratings...]")
+	if _, err := ratings.Update(ctx, db, opts.Ratings); err != nil {
+		log.Printf("failed to update ratings: %v", err)
+	}
+
+	fmt.Println("[Updating Divisions...]")
+	if _, err := divisions.Update(ctx, db); err != nil {
+		log.Printf("failed to update divisions: %v", err)
+	}
+
+	fmt.Println("[Updating Records...]")
+	if _, err := records.Update(ctx, db); err != nil {
+		log.Printf("failed to update records: %v", err)
+	}
+
+	// last, every derived collection is now consistent with the load
+	if err := data.MarkDerived(ctx, db, summary); err != nil {
+		log.Printf("%v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package predict
+
+import (
+	"math"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/anthonybliss1/ufc-api/scrape/features"
+)
+
+// inputs of the model, each one the difference between fighter A and fighter B
+var FeatureNames = []string{
+	"rating",  // Elo rating going into the fight
+	"age",     // years
+	"reach",   // inches
+	"height",  // inches
+	"slpm",    // significant strikes landed per minute
+	"sapm",    // significant strikes absorbed per minute
+	"str_acc", // striking accuracy
+	"str_def", // striking defence
+	"td_avg",  // takedowns per 15 minutes
+	"td_acc",  // takedown accuracy
+	"td_def",  // takedown defence
+	"sub_avg", // submission attempts per 15 minutes
+}
+
+// what the model knows about one side of a matchup, all of it as of before the fight. the career
+// numbers come from features.Tracker in training and serving alike, never from the fighter profile
+// (its stats include every fight since)
+type Side struct {
+	Snapshot features.Snapshot // UFC numbers going into the fight
+	Reach    float64           // inches, NaN when unknown. physicals do not depend on results
+	Height   float64           // inches, NaN when unknown
+	Rating   float64           // Elo rating going into the fight
+}
+
+// the paths of an upcoming fight a prediction reads: its event (for the date) and the fighters' id, name, date
+// of birth (age) and the physicals of NewSide. for callers that only fetch some fields
+var UpcomingFightFields = []string{
+	"upcoming_event_id",
+	"tale_of_the_tape._id",
+	"tale_of_the_tape.name",
+	"tale_of_the_tape.dob",
+	"tale_of_the_tape.height",
+	"tale_of_the_tape.reach_in",
+}
+
+// a side from the pre-fight snapshot, the physicals of the fighter's profile and their rating
+func NewSide(snapshot features.Snapshot, profile data.Fighter, rating float64) Side {
+	s := Side{Snapshot: snapshot, Reach: math.NaN(), Height: math.NaN(), Rating: rating}
+	if v, ok := data.ReachInches(profile.ReachIN); ok {
+		s.Reach = v
+	}
+	if v, ok := data.HeightInches(profile.Height); ok {
+		s.Height = v
+	}
+	return s
+}
+
+// a - b, 0 (no information) when either side is NaN
+func diff(a, b float64) float64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return 0
+	}
+	return a - b
+}
+
+// A - B for every feature in FeatureNames
+func Features(a, b Side) []float64 {
+	sa, sb := a.Snapshot, b.Snapshot
+
+	return []float64{
+		a.Rating - b.Rating,
+		diff(sa.Age, sb.Age),
+		diff(a.Reach, b.Reach),
+		diff(a.Height, b.Height),
+		diff(sa.SLpM, sb.SLpM),
+		diff(sa.SApM, sb.SApM),
+		diff(sa.StrAcc, sb.StrAcc),
+		diff(sa.StrDef, sb.StrDef),
+		diff(sa.TdAvg, sb.TdAvg),
+		diff(sa.TdAcc, sb.TdAcc),
+		diff(sa.TdDef, sb.TdDef),
+		diff(sa.SubAvg, sb.SubAvg),
+	}
+}
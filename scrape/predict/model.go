@@ -0,0 +1,85 @@
+package predict
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// 2: career features from the pre-fight numbers instead of the fighter profile
+const modelVersion = 2
+
+// logistic regression on feature differentials, saved as json. there is no intercept: swapping the
+// fighters negates every feature, so P(A beats B) = 1 - P(B beats A) holds by construction
+type Model struct {
+	Version     int       `json:"version"`
+	TrainedAt   time.Time `json:"trained_at"`
+	Samples     int       `json:"samples"`      // training rows (every fight counted from both corners)
+	RatingModel string    `json:"rating_model"` // model of the stored ratings it was trained on, the served ones must match
+	LogLoss     float64   `json:"log_loss"`     // on the training rows
+	Features    []string  `json:"features"`
+	Scale       []float64 `json:"scale"`   // each feature is divided by its scale before it is weighted
+	Weights     []float64 `json:"weights"` // log-odds per scaled unit
+}
+
+// one feature's share of a prediction
+type Contribution struct {
+	Feature      string  `json:"feature"`
+	Difference   float64 `json:"difference"`   // A - B
+	Contribution float64 `json:"contribution"` // log-odds added in favour of A
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+// probability that A wins given the A - B features
+func (m *Model) Probability(x []float64) float64 {
+	z := 0.0
+	for i, w := range m.Weights {
+		z += w * x[i] / m.Scale[i]
+	}
+	return sigmoid(z)
+}
+
+// probability that A wins and how much each feature moved it
+func (m *Model) Explain(x []float64) (float64, []Contribution) {
+	contributions := make([]Contribution, len(m.Weights))
+	for i, w := range m.Weights {
+		contributions[i] = Contribution{Feature: m.Features[i], Difference: x[i], Contribution: w * x[i] / m.Scale[i]}
+	}
+	return m.Probability(x), contributions
+}
+
+func (m *Model) Save(path string) error {
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
+func LoadModel(path string) (*Model, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Model
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("invalid model file: %v", err)
+	}
+	if m.Version != modelVersion {
+		return nil, fmt.Errorf("model version %d is not supported (want %d)", m.Version, modelVersion)
+	}
+	if m.RatingModel == "" {
+		return nil, fmt.Errorf("invalid model file: no rating_model")
+	}
+	if len(m.Weights) != len(m.Features) || len(m.Scale) != len(m.Features) {
+		return nil, fmt.Errorf("invalid model file: %d features, %d weights, %d scales", len(m.Features), len(m.Weights), len(m.Scale))
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,222 @@
+package predict
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"github.com/anthonybliss1/ufc-api/scrape/features"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// one training row: A - B features and 1 when A won
+type Sample struct {
+	X []float64
+	Y float64
+}
+
+type TrainOptions struct {
+	Epochs       int     // full passes of gradient descent
+	LearningRate float64 // step size on the scaled features
+	L2           float64 // weight penalty
+}
+
+func DefaultTrainOptions() TrainOptions {
+	return TrainOptions{Epochs: 500, LearningRate: 0.5, L2: 1e-3}
+}
+
+// add every sample again from the other corner so the model has no corner bias
+func Mirror(samples []Sample) []Sample {
+	out := make([]Sample, 0, len(samples)*2)
+	for _, s := range samples {
+		neg := make([]float64, len(s.X))
+		for i, v := range s.X {
+			neg[i] = -v
+		}
+		out = append(out, s, Sample{X: neg, Y: 1 - s.Y})
+	}
+	return out
+}
+
+// fit a logistic regression on the samples with batch gradient descent. features are scaled by their
+// root mean square so one learning rate suits them all
+func Fit(features []string, samples []Sample, opts TrainOptions) *Model {
+	n, k := len(samples), len(features)
+
+	m := &Model{
+		Version:   modelVersion,
+		TrainedAt: time.Now().UTC(),
+		Samples:   n,
+		Features:  features,
+		Scale:     make([]float64, k),
+		Weights:   make([]float64, k),
+	}
+	if n == 0 {
+		for i := range m.Scale {
+			m.Scale[i] = 1
+		}
+		return m
+	}
+
+	for _, s := range samples {
+		for i, v := range s.X {
+			m.Scale[i] += v * v
+		}
+	}
+	for i := range m.Scale {
+		m.Scale[i] = math.Sqrt(m.Scale[i] / float64(n))
+		if m.Scale[i] == 0 {
+			m.Scale[i] = 1
+		}
+	}
+
+	grad := make([]float64, k)
+	for epoch := 0; epoch < opts.Epochs; epoch++ {
+		for i := range grad {
+			grad[i] = opts.L2 * m.Weights[i]
+		}
+		for _, s := range samples {
+			err := m.Probability(s.X) - s.Y
+			for i, v := range s.X {
+				grad[i] += err * v / m.Scale[i] / float64(n)
+			}
+		}
+		for i := range m.Weights {
+			m.Weights[i] -= opts.LearningRate * grad[i]
+		}
+	}
+
+	m.LogLoss = LogLoss(m, samples)
+	return m
+}
+
+// mean negative log likelihood of the samples
+func LogLoss(m *Model, samples []Sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	const eps = 1e-15
+	total := 0.0
+	for _, s := range samples {
+		p := math.Min(math.Max(m.Probability(s.X), eps), 1-eps)
+		total -= s.Y*math.Log(p) + (1-s.Y)*math.Log(1-p)
+	}
+	return total / float64(len(samples))
+}
+
+// every fighter profile by id, for the physicals and dates of birth
+func loadProfiles(ctx context.Context, db *mongo.Database) (map[string]data.Fighter, error) {
+	cur, err := db.Collection("fighters").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"dob": 1, "height": 1, "reach_in": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fighters: %v", err)
+	}
+	var list []data.Fighter
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("decode fighters failed: %v", err)
+	}
+	fighters := make(map[string]data.Fighter, len(list))
+	for _, f := range list {
+		fighters[f.ID] = f
+	}
+	return fighters, nil
+}
+
+// the rating going into every stored fight by snapshot id (fight id + ":" + fighter id) and the rating
+// model that produced them. the api serves ratings from the same collection, so the model is trained on
+// the scale it is fed later
+func loadRatingsBefore(ctx context.Context, db *mongo.Database) (map[string]float64, string, error) {
+	coll := db.Collection("ratings")
+
+	var models []string
+	if err := coll.Distinct(ctx, "model", bson.M{}).Decode(&models); err != nil {
+		return nil, "", fmt.Errorf("failed to read rating models: %v", err)
+	}
+	switch {
+	case len(models) == 0:
+		return nil, "", fmt.Errorf("no ratings stored, run a load first")
+	case len(models) > 1:
+		return nil, "", fmt.Errorf("ratings of several models stored (%v), run a load to replay them", models)
+	}
+
+	cur, err := coll.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"before": 1}))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read ratings: %v", err)
+	}
+	var snapshots []data.RatingSnapshot
+	if err := cur.All(ctx, &snapshots); err != nil {
+		return nil, "", fmt.Errorf("decode ratings failed: %v", err)
+	}
+
+	before := make(map[string]float64, len(snapshots))
+	for _, s := range snapshots {
+		before[s.ID] = s.Before
+	}
+	return before, models[0], nil
+}
+
+// training rows from every decided fight, all of them as of before the fight: career numbers from
+// features.Bouts (earlier dates only), the stored rating going into the fight and the physicals of the
+// profile. the profile's career stats are never used, they include the fight and every one after it.
+// returns the rating model of the ratings used
+func TrainingSamples(ctx context.Context, db *mongo.Database) ([]Sample, string, error) {
+	history, err := data.LoadFightHistory(ctx, db)
+	if err != nil {
+		return nil, "", err
+	}
+	fighters, err := loadProfiles(ctx, db)
+	if err != nil {
+		return nil, "", err
+	}
+	before, ratingModel, err := loadRatingsBefore(ctx, db)
+	if err != nil {
+		return nil, "", err
+	}
+	dobs := make(map[string]*time.Time, len(fighters))
+	for id, f := range fighters {
+		dobs[id] = f.DOB
+	}
+
+	samples := make([]Sample, 0, len(history))
+
+	for _, b := range features.Bouts(history, dobs) {
+		pa, pb := b.Fight.Participants[0], b.Fight.Participants[1]
+		if pa.Outcome != "W" && pa.Outcome != "L" {
+			continue
+		}
+		fa, oka := fighters[pa.FighterID]
+		fb, okb := fighters[pb.FighterID]
+		ra, rka := before[b.Fight.ID+":"+pa.FighterID]
+		rb, rkb := before[b.Fight.ID+":"+pb.FighterID]
+		if !oka || !okb || !rka || !rkb {
+			continue
+		}
+
+		y := 0.0
+		if pa.Outcome == "W" {
+			y = 1
+		}
+		samples = append(samples, Sample{X: Features(NewSide(b.Row.A, fa, ra), NewSide(b.Row.B, fb, rb)), Y: y})
+	}
+
+	return samples, ratingModel, nil
+}
+
+// train the model from the fights collection
+func Train(ctx context.Context, db *mongo.Database, opts TrainOptions) (*Model, error) {
+	samples, ratingModel, err := TrainingSamples(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no decided fights to train on")
+	}
+
+	m := Fit(FeatureNames, Mirror(samples), opts)
+	m.RatingModel = ratingModel
+	return m, nil
+}
@@ -0,0 +1,144 @@
+package ratings
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+)
+
+// rating engine parameters. snapshots record them as their model so a change is detected and replayed
+type Options struct {
+	K            float64 // largest possible rating change of one fight
+	Initial      float64 // rating going into a fighter's first fight
+	FinishWeight float64 // K multiplier for KO/TKO and submission results, 0 or 1 to ignore the method
+	Rebuild      bool    // replay every fight instead of only the ones without snapshots
+}
+
+func DefaultOptions() Options {
+	return Options{K: 32, Initial: 1500}
+}
+
+// i.e. "elo-k32-i1500" or "elo-k32-i1500-fw1.5"
+func (o Options) Model() string {
+	model := fmt.Sprintf("elo-k%g-i%g", o.K, o.Initial)
+	if o.finishWeighted() {
+		model += fmt.Sprintf("-fw%g", o.FinishWeight)
+	}
+	return model
+}
+
+func (o Options) finishWeighted() bool {
+	return o.FinishWeight > 0 && o.FinishWeight != 1
+}
+
+type fighterState struct {
+	rating float64
+	fights int
+}
+
+// Elo ratings of every fighter seen so far. fights must be applied in the order they happened
+type Elo struct {
+	opts    Options
+	model   string
+	fighter map[string]*fighterState
+}
+
+func NewElo(opts Options) *Elo {
+	return &Elo{opts: opts, model: opts.Model(), fighter: make(map[string]*fighterState)}
+}
+
+// continue from a stored rating (the newest snapshot of the fighter)
+func (e *Elo) Set(fighterID string, rating float64, fights int) {
+	e.fighter[fighterID] = &fighterState{rating: rating, fights: fights}
+}
+
+// current rating and rated fights of a fighter, the initial rating when they have not fought yet
+func (e *Elo) Rating(fighterID string) (float64, int) {
+	if s, ok := e.fighter[fighterID]; ok {
+		return s.rating, s.fights
+	}
+	return e.opts.Initial, 0
+}
+
+// chance that a beats b according to their current ratings
+func (e *Elo) Expected(a, b string) float64 {
+	ra, _ := e.Rating(a)
+	rb, _ := e.Rating(b)
+	return expected(ra, rb)
+}
+
+func expected(ra, rb float64) float64 {
+	return 1 / (1 + math.Pow(10, (rb-ra)/400))
+}
+
+// score of the first participant, false for results that are not rated (no contest, missing outcome)
+func score(outcome string) (float64, bool) {
+	switch outcome {
+	case "W":
+		return 1, true
+	case "L":
+		return 0, true
+	case "D":
+		return 0.5, true
+	}
+	return 0, false
+}
+
+// true when Apply rates the fight: two fighters with ids and a win, loss or draw
+func Rateable(f data.Fight) bool {
+	if len(f.Participants) != 2 {
+		return false
+	}
+	if f.Participants[0].FighterID == "" || f.Participants[1].FighterID == "" {
+		return false
+	}
+	_, ok := score(f.Participants[0].Outcome)
+	return ok
+}
+
+// rate one fight and return the snapshot of both fighters, nil when the fight is not rated
+func (e *Elo) Apply(f data.DatedFight) []data.RatingSnapshot {
+	if !Rateable(f.Fight) {
+		return nil
+	}
+	a, b := f.Participants[0], f.Participants[1]
+	sa, _ := score(a.Outcome)
+
+	k := e.opts.K
+	if e.opts.finishWeighted() && sa != 0.5 && data.IsFinish(f.Method) {
+		k *= e.opts.FinishWeight
+	}
+
+	ra, na := e.Rating(a.FighterID)
+	rb, nb := e.Rating(b.FighterID)
+
+	delta := k * (sa - expected(ra, rb))
+	e.Set(a.FighterID, ra+delta, na+1)
+	e.Set(b.FighterID, rb-delta, nb+1)
+
+	division := data.DivisionOf(f.FightDetail)
+	snapshot := func(me, opp data.FightStats, before, after float64, fights int) data.RatingSnapshot {
+		return data.RatingSnapshot{
+			ID:          f.ID + ":" + me.FighterID,
+			FighterID:   me.FighterID,
+			FighterName: me.FighterName,
+			OpponentID:  opp.FighterID,
+			FightID:     f.ID,
+			EventID:     f.EventID,
+			Date:        f.EventDate,
+			Division:    division,
+			Outcome:     me.Outcome,
+			Method:      f.Method,
+			Before:      before,
+			After:       after,
+			Fights:      fights,
+			Model:       e.model,
+		}
+	}
+
+	return []data.RatingSnapshot{
+		snapshot(a, b, ra, ra+delta, na+1),
+		snapshot(b, a, rb, rb-delta, nb+1),
+	}
+}
@@ -0,0 +1,139 @@
+package ratings
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+)
+
+func bout(method, outcomeA, outcomeB string) data.DatedFight {
+	return data.DatedFight{
+		Fight: data.Fight{
+			ID:          "f1",
+			EventID:     "e1",
+			FightDetail: "Lightweight Bout",
+			Method:      method,
+			Participants: []data.FightStats{
+				{FighterID: "a", FighterName: "A", Outcome: outcomeA},
+				{FighterID: "b", FighterName: "B", Outcome: outcomeB},
+			},
+		},
+		EventDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		ra, rb float64 // ratings going in, 0 for a first fight
+		fight  data.DatedFight
+		afterA float64
+		afterB float64
+	}{
+		{name: "even win", opts: DefaultOptions(), fight: bout("Decision - Unanimous", "W", "L"), afterA: 1516, afterB: 1484},
+		{name: "even loss", opts: DefaultOptions(), fight: bout("Decision - Unanimous", "L", "W"), afterA: 1484, afterB: 1516},
+		{name: "even draw", opts: DefaultOptions(), fight: bout("Decision - Split", "D", "D"), afterA: 1500, afterB: 1500},
+		{name: "favourite wins", opts: DefaultOptions(), ra: 1600, rb: 1400, fight: bout("Decision - Unanimous", "W", "L"), afterA: 1607.6880, afterB: 1392.3120},
+		{name: "underdog wins", opts: DefaultOptions(), ra: 1400, rb: 1600, fight: bout("Decision - Unanimous", "W", "L"), afterA: 1424.3120, afterB: 1575.6880},
+		{name: "weighted finish", opts: Options{K: 32, Initial: 1500, FinishWeight: 1.5}, fight: bout("KO/TKO", "W", "L"), afterA: 1524, afterB: 1476},
+		{name: "weighted submission", opts: Options{K: 32, Initial: 1500, FinishWeight: 1.5}, fight: bout("Submission", "L", "W"), afterA: 1476, afterB: 1524},
+		{name: "weighted decision", opts: Options{K: 32, Initial: 1500, FinishWeight: 1.5}, fight: bout("Decision - Majority", "W", "L"), afterA: 1516, afterB: 1484},
+		{name: "weighted draw", opts: Options{K: 32, Initial: 1500, FinishWeight: 1.5}, fight: bout("KO/TKO", "D", "D"), afterA: 1500, afterB: 1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewElo(tt.opts)
+			if tt.ra != 0 {
+				e.Set("a", tt.ra, 3)
+				e.Set("b", tt.rb, 3)
+			}
+
+			snaps := e.Apply(tt.fight)
+			if len(snaps) != 2 {
+				t.Fatalf("got %d snapshots, want 2", len(snaps))
+			}
+			if math.Abs(snaps[0].After-tt.afterA) > 0.001 || math.Abs(snaps[1].After-tt.afterB) > 0.001 {
+				t.Errorf("after = %.4f / %.4f, want %.4f / %.4f", snaps[0].After, snaps[1].After, tt.afterA, tt.afterB)
+			}
+
+			// the engine carries on from the snapshot it returned
+			if r, _ := e.Rating("a"); r != snaps[0].After {
+				t.Errorf("Rating(a) = %v, snapshot says %v", r, snaps[0].After)
+			}
+			if snaps[0].OpponentID != "b" || snaps[1].OpponentID != "a" {
+				t.Errorf("opponents = %q / %q", snaps[0].OpponentID, snaps[1].OpponentID)
+			}
+			if snaps[0].Model != tt.opts.Model() || snaps[0].Division != "lightweight" {
+				t.Errorf("model %q division %q", snaps[0].Model, snaps[0].Division)
+			}
+		})
+	}
+}
+
+func TestApplyCountsFights(t *testing.T) {
+	e := NewElo(DefaultOptions())
+	e.Set("a", 1550, 4)
+
+	snaps := e.Apply(bout("KO/TKO", "W", "L"))
+	if snaps[0].Before != 1550 || snaps[0].Fights != 5 {
+		t.Errorf("a: before %v fights %d, want 1550 and 5", snaps[0].Before, snaps[0].Fights)
+	}
+	if snaps[1].Before != 1500 || snaps[1].Fights != 1 {
+		t.Errorf("b: before %v fights %d, want 1500 and 1", snaps[1].Before, snaps[1].Fights)
+	}
+}
+
+func TestApplySkipsUnrated(t *testing.T) {
+	noID := bout("KO/TKO", "W", "L")
+	noID.Participants[1].FighterID = ""
+
+	single := bout("KO/TKO", "W", "L")
+	single.Participants = single.Participants[:1]
+
+	tests := []struct {
+		name  string
+		fight data.DatedFight
+	}{
+		{"no contest", bout("Overturned", "NC", "NC")},
+		{"missing outcome", bout("", "", "")},
+		{"missing fighter id", noID},
+		{"one participant", single},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewElo(DefaultOptions())
+			if snaps := e.Apply(tt.fight); snaps != nil {
+				t.Errorf("got %d snapshots, want none", len(snaps))
+			}
+			if Rateable(tt.fight.Fight) {
+				t.Error("Rateable = true")
+			}
+			if _, n := e.Rating("a"); n != 0 {
+				t.Errorf("a has %d rated fights", n)
+			}
+		})
+	}
+}
+
+func TestModel(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{DefaultOptions(), "elo-k32-i1500"},
+		{Options{K: 24, Initial: 1200}, "elo-k24-i1200"},
+		{Options{K: 32, Initial: 1500, FinishWeight: 1}, "elo-k32-i1500"},
+		{Options{K: 32, Initial: 1500, FinishWeight: 1.5}, "elo-k32-i1500-fw1.5"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.Model(); got != tt.want {
+			t.Errorf("%+v: Model() = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package ratings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const writeBatchSize = 1000
+
+// bring the 'ratings' collection up to date with the fights collection. only the fights from the earliest
+// rateable one without snapshots onwards are replayed, starting from the ratings stored before it. no
+// contests and other fights Apply skips never get a snapshot and are ignored when looking for it.
+// snapshots of a different model (changed Options) are all replayed. returns the number of snapshots written
+func Update(ctx context.Context, db *mongo.Database, opts Options) (int, error) {
+	coll := db.Collection("ratings")
+	model := opts.Model()
+
+	if !opts.Rebuild {
+		n, err := coll.CountDocuments(ctx, bson.M{"model": bson.M{"$ne": model}}, options.Count().SetLimit(1))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read ratings: %v", err)
+		}
+		opts.Rebuild = n > 0
+	}
+
+	history, err := data.LoadFightHistory(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	elo := NewElo(opts)
+
+	// replay everything from the first rateable fight without snapshots, later fights included since their
+	// ratings depend on it
+	var from time.Time
+	if !opts.Rebuild {
+		var rated []string
+		if err := coll.Distinct(ctx, "fight_id", bson.M{}).Decode(&rated); err != nil {
+			return 0, fmt.Errorf("failed to read rated fights: %v", err)
+		}
+		seen := make(map[string]bool, len(rated))
+		for _, id := range rated {
+			seen[id] = true
+		}
+
+		first := -1
+		for i, f := range history {
+			if Rateable(f.Fight) && !seen[f.ID] {
+				first = i
+				break
+			}
+		}
+		if first < 0 {
+			fmt.Println("[Ratings up to date]")
+			return 0, nil
+		}
+		from = history[first].EventDate
+
+		if err := seedRatings(ctx, coll, elo, from); err != nil {
+			return 0, err
+		}
+	}
+
+	filter := bson.M{}
+	if !from.IsZero() {
+		filter["date"] = bson.M{"$gte": from}
+	}
+	if _, err := coll.DeleteMany(ctx, filter); err != nil {
+		return 0, fmt.Errorf("failed to clear ratings: %v", err)
+	}
+
+	written := 0
+	batch := make([]mongo.WriteModel, 0, writeBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("failed to write ratings: %v", err)
+		}
+		written += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for _, f := range history {
+		if f.EventDate.Before(from) {
+			continue
+		}
+		for _, s := range elo.Apply(f) {
+			batch = append(batch, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": s.ID}).SetReplacement(s).SetUpsert(true))
+		}
+		if len(batch) >= writeBatchSize {
+			if err := flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return written, err
+	}
+
+	if from.IsZero() {
+		fmt.Printf("✅ [Ratings rebuilt | %d snapshots | %s]\n", written, model)
+	} else {
+		fmt.Printf("✅ [Ratings replayed from %s | %d snapshots | %s]\n", from.Format("2006-01-02"), written, model)
+	}
+
+	return written, nil
+}
+
+// load the rating every fighter had before 'from' (their newest earlier snapshot)
+func seedRatings(ctx context.Context, coll *mongo.Collection, elo *Elo, from time.Time) error {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"date": bson.M{"$lt": from}}},
+		bson.M{"$sort": bson.D{{Key: "date", Value: -1}, {Key: "fight_id", Value: -1}}},
+		bson.M{"$group": bson.M{
+			"_id":    "$fighter_id",
+			"after":  bson.M{"$first": "$after"},
+			"fights": bson.M{"$first": "$fights"},
+		}},
+	}
+
+	cur, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to read current ratings: %v", err)
+	}
+
+	var current []struct {
+		FighterID string  `bson:"_id"`
+		After     float64 `bson:"after"`
+		Fights    int     `bson:"fights"`
+	}
+	if err := cur.All(ctx, &current); err != nil {
+		return fmt.Errorf("decode ratings failed: %v", err)
+	}
+
+	for _, c := range current {
+		elo.Set(c.FighterID, c.After, c.Fights)
+	}
+	return nil
+}
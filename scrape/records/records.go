@@ -0,0 +1,246 @@
+package records
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	writeBatchSize = 1000
+
+	// places kept per record list
+	listSize = 100
+)
+
+// running state of one fighter while the fights are replayed
+type tracker struct {
+	record    data.FighterRecord
+	lastFight time.Time
+	winFrom   time.Time // first win of the current streak
+	longest   struct {
+		from, to time.Time
+		ongoing  bool
+	}
+}
+
+func isTitleFight(fightDetail string) bool {
+	return strings.Contains(strings.ToLower(fightDetail), "title")
+}
+
+// every fighter's records and the ranked record lists from the fights, which must be in date order
+func Compute(history []data.DatedFight) (map[string]*data.FighterRecord, []data.RecordList) {
+	trackers := make(map[string]*tracker)
+	var finishes []data.RecordEntry
+
+	for _, f := range history {
+		finish := data.IsFinish(f.Method)
+		elapsed, timed := data.ElapsedSeconds(f.Round, f.EndTime, f.TimeFormat)
+
+		for _, p := range f.Participants {
+			t, ok := trackers[p.FighterID]
+			if !ok {
+				t = &tracker{record: data.FighterRecord{ID: p.FighterID}}
+				trackers[p.FighterID] = t
+			}
+			t.record.Name = p.FighterName
+			t.lastFight = f.EventDate
+
+			s := &t.record.Streaks
+			switch p.Outcome {
+			case "W":
+				if s.Current <= 0 {
+					t.winFrom = f.EventDate
+				}
+				s.Current = max(s.Current, 0) + 1
+				s.CurrentWin = s.Current
+				if s.Current > s.LongestWin {
+					s.LongestWin = s.Current
+					t.longest.from, t.longest.to = t.winFrom, f.EventDate
+				}
+				t.longest.ongoing = s.Current == s.LongestWin && t.longest.from.Equal(t.winFrom)
+
+				if isTitleFight(f.FightDetail) {
+					t.record.TitleFightWins++
+				}
+				if finish {
+					t.record.Finishes++
+					if timed && (t.record.FastestFinish == 0 || elapsed < t.record.FastestFinish) {
+						t.record.FastestFinish = elapsed
+					}
+					if timed {
+						finishes = append(finishes, finishEntry(f, p, elapsed))
+					}
+				}
+			case "L":
+				s.Current = min(s.Current, 0) - 1
+				s.CurrentWin = 0
+				t.longest.ongoing = false
+			case "D":
+				s.Current = 0
+				s.CurrentWin = 0
+				t.longest.ongoing = false
+			}
+			// no contests leave the streaks alone
+		}
+	}
+
+	fighters := make(map[string]*data.FighterRecord, len(trackers))
+	for id, t := range trackers {
+		if t.record.Streaks.LongestWin > 0 {
+			from, to := t.longest.from, t.longest.to
+			t.record.Streaks.LongestWinFrom, t.record.Streaks.LongestWinTo = &from, &to
+		}
+		fighters[id] = &t.record
+	}
+
+	lists := []data.RecordList{
+		ranked(data.RecordCurrentWinStreak, "wins", fighterEntries(trackers, func(t *tracker) (data.RecordEntry, bool) {
+			s := t.record.Streaks
+			if s.CurrentWin == 0 {
+				return data.RecordEntry{}, false
+			}
+			from, to := t.winFrom, t.lastFight
+			return data.RecordEntry{Value: s.CurrentWin, From: &from, To: &to, Ongoing: true}, true
+		}), true),
+		ranked(data.RecordLongestWinStreak, "wins", fighterEntries(trackers, func(t *tracker) (data.RecordEntry, bool) {
+			s := t.record.Streaks
+			if s.LongestWin == 0 {
+				return data.RecordEntry{}, false
+			}
+			return data.RecordEntry{Value: s.LongestWin, From: s.LongestWinFrom, To: s.LongestWinTo, Ongoing: t.longest.ongoing}, true
+		}), true),
+		ranked(data.RecordFastestFinishes, "seconds", finishes, false),
+		ranked(data.RecordMostFinishes, "wins", fighterEntries(trackers, func(t *tracker) (data.RecordEntry, bool) {
+			return data.RecordEntry{Value: t.record.Finishes}, t.record.Finishes > 0
+		}), true),
+		ranked(data.RecordMostTitleWins, "wins", fighterEntries(trackers, func(t *tracker) (data.RecordEntry, bool) {
+			return data.RecordEntry{Value: t.record.TitleFightWins}, t.record.TitleFightWins > 0
+		}), true),
+	}
+
+	return fighters, lists
+}
+
+func finishEntry(f data.DatedFight, winner data.FightStats, elapsed int) data.RecordEntry {
+	e := data.RecordEntry{
+		FighterID:   winner.FighterID,
+		FighterName: winner.FighterName,
+		Value:       elapsed,
+		FightID:     f.ID,
+		Method:      f.Method,
+		Division:    data.DivisionOf(f.FightDetail),
+		From:        &f.EventDate,
+	}
+	for _, p := range f.Participants {
+		if p.FighterID != winner.FighterID {
+			e.Opponent = p.FighterName
+		}
+	}
+	return e
+}
+
+// an entry per fighter that has one
+func fighterEntries(trackers map[string]*tracker, entry func(*tracker) (data.RecordEntry, bool)) []data.RecordEntry {
+	var entries []data.RecordEntry
+	for _, t := range trackers {
+		e, ok := entry(t)
+		if !ok {
+			continue
+		}
+		e.FighterID, e.FighterName = t.record.ID, t.record.Name
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// sort the entries (highest value first when desc), keep the top listSize and number them. equal values
+// share a rank and are ordered by the earlier date, then fighter id
+func ranked(recordType, unit string, entries []data.RecordEntry, desc bool) data.RecordList {
+	slices.SortFunc(entries, func(a, b data.RecordEntry) int {
+		if c := cmp.Compare(a.Value, b.Value); c != 0 {
+			if desc {
+				return -c
+			}
+			return c
+		}
+		if a.From != nil && b.From != nil {
+			if c := a.From.Compare(*b.From); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.FighterID, b.FighterID)
+	})
+
+	if len(entries) > listSize {
+		entries = entries[:listSize]
+	}
+	for i := range entries {
+		if i > 0 && entries[i].Value == entries[i-1].Value {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+
+	return data.RecordList{Type: recordType, Unit: unit, Entries: entries, UpdatedAt: time.Now().UTC()}
+}
+
+// rebuild the 'fighterRecords' and 'records' collections from the fights. returns the fighters written
+func Update(ctx context.Context, db *mongo.Database) (int, error) {
+	history, err := data.LoadFightHistory(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	fighters, lists := Compute(history)
+
+	coll := db.Collection("fighterRecords")
+	ids := make([]string, 0, len(fighters))
+	batch := make([]mongo.WriteModel, 0, writeBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("failed to write fighter records: %v", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for id, rec := range fighters {
+		ids = append(ids, id)
+		batch = append(batch, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": id}).SetReplacement(rec).SetUpsert(true))
+		if len(batch) >= writeBatchSize {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	if _, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$nin": ids}}); err != nil {
+		return len(ids), fmt.Errorf("failed to clear fighter records: %v", err)
+	}
+
+	for _, list := range lists {
+		_, err := db.Collection("records").ReplaceOne(ctx, bson.M{"_id": list.Type}, list, options.Replace().SetUpsert(true))
+		if err != nil {
+			return len(ids), fmt.Errorf("failed to write %s: %v", list.Type, err)
+		}
+	}
+
+	fmt.Printf("✅ [Records rebuilt | %d fighters | %d lists]\n", len(ids), len(lists))
+
+	return len(ids), nil
+}
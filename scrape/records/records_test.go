@@ -0,0 +1,185 @@
+package records
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/data"
+)
+
+func day(d int) time.Time {
+	return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+// 'a' against 'b' with a's outcome, ended in round 1 at 'end' of a 3 x 5 minute bout
+func bout(id string, on time.Time, outcome, method, end string) data.DatedFight {
+	other := map[string]string{"W": "L", "L": "W", "D": "D", "NC": "NC"}[outcome]
+	return data.DatedFight{
+		Fight: data.Fight{
+			ID:          id,
+			FightDetail: "Lightweight Bout",
+			Method:      method,
+			Round:       1,
+			EndTime:     end,
+			TimeFormat:  "3 Rnd (5-5-5)",
+			Participants: []data.FightStats{
+				{FighterID: "a", FighterName: "A", Outcome: outcome},
+				{FighterID: "b", FighterName: "B", Outcome: other},
+			},
+		},
+		EventDate: on,
+	}
+}
+
+func TestComputeFighter(t *testing.T) {
+	title := bout("3", day(3), "W", "Decision - Unanimous", "5:00")
+	title.FightDetail = "UFC Lightweight Title Bout"
+
+	overtime := bout("2", day(2), "W", "KO/TKO", "1:30")
+	overtime.Round, overtime.TimeFormat = 2, "1 Rnd + 2OT (15-3-3)"
+
+	tests := []struct {
+		name     string
+		history  []data.DatedFight
+		streaks  data.Streaks // longest dates are checked separately
+		from, to time.Time
+		finishes int
+		fastest  int
+		titles   int
+	}{
+		{
+			name:    "win streak",
+			history: []data.DatedFight{bout("1", day(1), "W", "Decision - Split", "5:00"), bout("2", day(2), "W", "Decision - Split", "5:00")},
+			streaks: data.Streaks{Current: 2, CurrentWin: 2, LongestWin: 2},
+			from:    day(1), to: day(2),
+		},
+		{
+			name: "streak broken by a loss",
+			history: []data.DatedFight{
+				bout("1", day(1), "W", "Decision - Split", "5:00"),
+				bout("2", day(2), "W", "Decision - Split", "5:00"),
+				bout("3", day(3), "L", "Decision - Split", "5:00"),
+				bout("4", day(4), "W", "Decision - Split", "5:00"),
+			},
+			streaks: data.Streaks{Current: 1, CurrentWin: 1, LongestWin: 2},
+			from:    day(1), to: day(2),
+		},
+		{
+			name:    "losing streak",
+			history: []data.DatedFight{bout("1", day(1), "W", "Decision - Split", "5:00"), bout("2", day(2), "L", "KO/TKO", "1:00"), bout("3", day(3), "L", "KO/TKO", "1:00")},
+			streaks: data.Streaks{Current: -2, CurrentWin: 0, LongestWin: 1},
+			from:    day(1), to: day(1),
+		},
+		{
+			name:    "no contest keeps the streak",
+			history: []data.DatedFight{bout("1", day(1), "W", "Decision - Split", "5:00"), bout("2", day(2), "NC", "Overturned", "5:00"), bout("3", day(3), "W", "Decision - Split", "5:00")},
+			streaks: data.Streaks{Current: 2, CurrentWin: 2, LongestWin: 2},
+			from:    day(1), to: day(3),
+		},
+		{
+			name:    "draw breaks the streak",
+			history: []data.DatedFight{bout("1", day(1), "W", "Decision - Split", "5:00"), bout("2", day(2), "D", "Decision - Split", "5:00"), bout("3", day(3), "W", "Decision - Split", "5:00")},
+			streaks: data.Streaks{Current: 1, CurrentWin: 1, LongestWin: 1},
+			from:    day(1), to: day(1),
+		},
+		{
+			name: "fastest finish",
+			history: []data.DatedFight{
+				bout("1", day(1), "W", "KO/TKO", "2:10"),
+				bout("2", day(2), "W", "Submission", "0:45"),
+				bout("3", day(3), "W", "TKO - Doctor's Stoppage", "1:00"),
+				bout("4", day(4), "W", "Decision - Unanimous", "0:10"),
+			},
+			streaks: data.Streaks{Current: 4, CurrentWin: 4, LongestWin: 4},
+			from:    day(1), to: day(4),
+			finishes: 3, fastest: 45,
+		},
+		{
+			name:    "overtime finish and title win",
+			history: []data.DatedFight{bout("1", day(1), "L", "Decision - Split", "5:00"), overtime, title},
+			streaks: data.Streaks{Current: 2, CurrentWin: 2, LongestWin: 2},
+			from:    day(2), to: day(3),
+			finishes: 1, fastest: 990, titles: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fighters, _ := Compute(tt.history)
+			rec, ok := fighters["a"]
+			if !ok {
+				t.Fatal("no record for a")
+			}
+
+			s := rec.Streaks
+			if s.Current != tt.streaks.Current || s.CurrentWin != tt.streaks.CurrentWin || s.LongestWin != tt.streaks.LongestWin {
+				t.Errorf("streaks = %+v, want %+v", s, tt.streaks)
+			}
+			if s.LongestWinFrom == nil || !s.LongestWinFrom.Equal(tt.from) || !s.LongestWinTo.Equal(tt.to) {
+				t.Errorf("longest win streak %v - %v, want %v - %v", s.LongestWinFrom, s.LongestWinTo, tt.from, tt.to)
+			}
+			if rec.Finishes != tt.finishes || rec.FastestFinish != tt.fastest || rec.TitleFightWins != tt.titles {
+				t.Errorf("finishes %d fastest %d titles %d, want %d, %d, %d", rec.Finishes, rec.FastestFinish, rec.TitleFightWins, tt.finishes, tt.fastest, tt.titles)
+			}
+		})
+	}
+}
+
+func TestComputeLists(t *testing.T) {
+	// a and c both finish b in 30 seconds (a on the earlier date), then b beats a
+	history := []data.DatedFight{
+		bout("1", day(1), "W", "KO/TKO", "2:00"),
+		bout("2", day(2), "W", "Submission", "0:30"),
+		{
+			Fight: data.Fight{ID: "3", Method: "KO/TKO", Round: 1, EndTime: "0:30", TimeFormat: "3 Rnd (5-5-5)",
+				Participants: []data.FightStats{{FighterID: "c", FighterName: "C", Outcome: "W"}, {FighterID: "b", FighterName: "B", Outcome: "L"}}},
+			EventDate: day(3),
+		},
+		bout("4", day(4), "L", "Decision - Unanimous", "5:00"),
+	}
+
+	_, lists := Compute(history)
+	byType := make(map[string]data.RecordList)
+	for _, l := range lists {
+		byType[l.Type] = l
+	}
+
+	tests := []struct {
+		record string
+		want   []data.RecordEntry // rank, fighter and value
+	}{
+		{data.RecordFastestFinishes, []data.RecordEntry{
+			{Rank: 1, FighterID: "a", Value: 30},
+			{Rank: 1, FighterID: "c", Value: 30},
+			{Rank: 3, FighterID: "a", Value: 120},
+		}},
+		{data.RecordMostFinishes, []data.RecordEntry{
+			{Rank: 1, FighterID: "a", Value: 2},
+			{Rank: 2, FighterID: "c", Value: 1},
+		}},
+		{data.RecordCurrentWinStreak, []data.RecordEntry{
+			{Rank: 1, FighterID: "c", Value: 1},
+			{Rank: 1, FighterID: "b", Value: 1},
+		}},
+		{data.RecordLongestWinStreak, []data.RecordEntry{
+			{Rank: 1, FighterID: "a", Value: 2},
+			{Rank: 2, FighterID: "c", Value: 1},
+			{Rank: 2, FighterID: "b", Value: 1},
+		}},
+		{data.RecordMostTitleWins, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.record, func(t *testing.T) {
+			got := byType[tt.record].Entries
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				if got[i].Rank != w.Rank || got[i].FighterID != w.FighterID || got[i].Value != w.Value {
+					t.Errorf("entry %d = rank %d %s %d, want rank %d %s %d", i, got[i].Rank, got[i].FighterID, got[i].Value, w.Rank, w.FighterID, w.Value)
+				}
+			}
+		})
+	}
+}
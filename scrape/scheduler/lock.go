@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// returned by Acquire when another process currently holds the lock
+var ErrLocked = errors.New("lock is held by another process")
+
+// cause of the cancelled run context when the lease could not be renewed
+var ErrLeaseLost = errors.New("lock lease lost")
+
+// Lock is a lease stored in the 'locks' collection. only one owner can hold it at a time and
+// it expires on its own after the TTL so a crashed run can never block the next one forever
+type Lock struct {
+	coll  *mongo.Collection
+	name  string
+	owner string
+	ttl   time.Duration
+}
+
+func NewLock(db *mongo.Database, name string, ttl time.Duration) *Lock {
+	host, _ := os.Hostname()
+
+	return &Lock{
+		coll:  db.Collection("locks"),
+		name:  name,
+		owner: fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano()),
+		ttl:   ttl,
+	}
+}
+
+// take the lock if it is free, expired, or already ours
+func (l *Lock) Acquire(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	filter := bson.M{
+		"_id": l.name,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lt": now}},
+			bson.M{"owner": l.owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"owner":       l.owner,
+		"acquired_at": now,
+		"expires_at":  now.Add(l.ttl),
+	}}
+
+	// the upsert collides on _id when someone else holds an unexpired lease
+	_, err := l.coll.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	return err
+}
+
+// push the expiry forward while a long run (full refresh) is still going
+func (l *Lock) Renew(ctx context.Context) error {
+	res, err := l.coll.UpdateOne(ctx,
+		bson.M{"_id": l.name, "owner": l.owner},
+		bson.M{"$set": bson.M{"expires_at": time.Now().UTC().Add(l.ttl)}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLocked
+	}
+	return nil
+}
+
+func (l *Lock) Release(ctx context.Context) error {
+	_, err := l.coll.DeleteOne(ctx, bson.M{"_id": l.name, "owner": l.owner})
+	return err
+}
+
+// acquire the lock, keep it renewed and run fn. the lock is released once fn returns. fn's ctx is
+// cancelled with ErrLeaseLost as soon as someone else owns the lease, or once it has gone a whole TTL
+// without a renewal (it may have expired and been taken), so a run never keeps writing next to another
+func (l *Lock) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := l.Acquire(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+
+		renewed := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				rctx, rcancel := context.WithTimeout(context.Background(), l.ttl/3)
+				err := l.Renew(rctx)
+				rcancel()
+				if err == nil {
+					renewed = time.Now()
+					continue
+				}
+
+				fmt.Printf("[lock %s renew failed: %v]\n", l.name, err)
+				if errors.Is(err, ErrLocked) || time.Since(renewed) >= l.ttl {
+					cancel(fmt.Errorf("%w: %v", ErrLeaseLost, err))
+					return
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		close(done)
+		if err := l.Release(context.Background()); err != nil {
+			fmt.Printf("[lock %s release failed: %v]\n", l.name, err)
+		}
+	}()
+
+	err := fn(ctx)
+	if cause := context.Cause(ctx); errors.Is(cause, ErrLeaseLost) {
+		// the run failed because it was stopped, report why
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// a single recurring scrape (update, upcoming, full refresh)
+type Job struct {
+	Name  string                          // name shown in logs and on the status endpoint
+	Every time.Duration                   // time between successful runs, 0 disables the job
+	Run   func(ctx context.Context) error // collect and load the data
+}
+
+// how long to wait before retrying a job after consecutive failures
+type Backoff struct {
+	Base time.Duration // delay after the first failure, doubled for every failure after that
+	Max  time.Duration // upper bound of the delay
+}
+
+func (b Backoff) Delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	d := b.Base
+	for i := 1; i < failures && d < b.Max; i++ {
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// what the status endpoint reports for each job
+type JobStatus struct {
+	Name                string     `json:"name"`
+	Every               string     `json:"every"`
+	Running             bool       `json:"running"`
+	NextRun             time.Time  `json:"next_run"`
+	LastRun             *time.Time `json:"last_run,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastDuration        string     `json:"last_duration,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+type Scheduler struct {
+	jobs    []Job
+	lock    *Lock
+	jitter  time.Duration
+	backoff Backoff
+
+	runMu sync.Mutex // jobs share the scraped maps so only one may run at a time in this process
+
+	mu     sync.Mutex // guards status
+	status map[string]*JobStatus
+}
+
+func New(jobs []Job, lock *Lock, jitter time.Duration, backoff Backoff) *Scheduler {
+	s := &Scheduler{
+		lock:    lock,
+		jitter:  jitter,
+		backoff: backoff,
+		status:  make(map[string]*JobStatus, len(jobs)),
+	}
+
+	for _, j := range jobs {
+		if j.Every <= 0 {
+			fmt.Printf("[Job %s disabled]\n", j.Name)
+			continue
+		}
+		s.jobs = append(s.jobs, j)
+		s.status[j.Name] = &JobStatus{Name: j.Name, Every: j.Every.String(), NextRun: time.Now().Add(s.randJitter())}
+	}
+
+	return s
+}
+
+// run every job on its schedule until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j Job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j Job) {
+	for {
+		timer := time.NewTimer(time.Until(s.nextRun(j.Name)))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx, j)
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j Job) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	start := time.Now()
+	s.update(j.Name, func(st *JobStatus) { st.Running = true })
+
+	fmt.Printf("[Job %s starting...]\n", j.Name)
+
+	err := s.lock.Do(ctx, func(ctx context.Context) error {
+		return safeRun(ctx, j.Run)
+	})
+
+	s.update(j.Name, func(st *JobStatus) {
+		st.Running = false
+
+		if errors.Is(err, ErrLocked) {
+			// someone else is scraping, try again a bit later without counting it as a failure
+			st.NextRun = time.Now().Add(min(j.Every, s.backoff.Base) + s.randJitter())
+			fmt.Printf("[Job %s skipped: %v | next run %s]\n", j.Name, err, st.NextRun.Format(time.RFC3339))
+			return
+		}
+
+		st.LastRun = &start
+		st.LastDuration = time.Since(start).Round(time.Second).String()
+
+		if err != nil {
+			st.ConsecutiveFailures++
+			st.LastError = err.Error()
+			st.NextRun = time.Now().Add(s.backoff.Delay(st.ConsecutiveFailures) + s.randJitter())
+			fmt.Printf("[Job %s failed (%d in a row): %v | retrying %s]\n", j.Name, st.ConsecutiveFailures, err, st.NextRun.Format(time.RFC3339))
+			return
+		}
+
+		now := time.Now()
+		st.LastSuccess = &now
+		st.LastError = ""
+		st.ConsecutiveFailures = 0
+		st.NextRun = now.Add(j.Every + s.randJitter())
+		fmt.Printf("[Job %s completed in %s | next run %s]\n", j.Name, st.LastDuration, st.NextRun.Format(time.RFC3339))
+	})
+}
+
+// scrape errors come back from Run, a panic left anywhere else still becomes a failed run instead of killing the daemon
+func safeRun(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+func (s *Scheduler) randJitter() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return rand.N(s.jitter)
+}
+
+func (s *Scheduler) nextRun(name string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status[name].NextRun
+}
+
+func (s *Scheduler) update(name string, fn func(st *JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.status[name])
+}
+
+// snapshot of every job, ordered by name
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]JobStatus, 0, len(s.status))
+	for _, st := range s.status {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+
+	return out
+}
+
+// GET /status reports the last and next run of every job
+func (s *Scheduler) StatusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"jobs": s.Status()})
+	})
+	return mux
+}
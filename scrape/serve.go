@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/anthonybliss1/ufc-api/scrape/scheduler"
+	"github.com/anthonybliss1/ufc-api/scrape/utils"
+)
+
+// every mode writes the same collections so they all share one lock
+const (
+	scrapeLockName = "scrape"
+	scrapeLockTTL  = 30 * time.Minute
+)
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+
+	updateEvery := fs.Duration("update-every", 24*time.Hour, "time between update runs (0 disables)")
+	upcomingEvery := fs.Duration("upcoming-every", 6*time.Hour, "time between upcoming runs (0 disables)")
+	fullEvery := fs.Duration("full-every", 0, "time between complete refreshes (0 disables)")
+	jitter := fs.Duration("jitter", 5*time.Minute, "random delay added to every scheduled run")
+	backoffBase := fs.Duration("backoff-base", 5*time.Minute, "retry delay after the first failure, doubled per consecutive failure")
+	backoffMax := fs.Duration("backoff-max", 6*time.Hour, "maximum retry delay after failures")
+	addr := fs.String("addr", "0.0.0.0:8001", "address of the status endpoint")
+	tx := fs.Bool("tx", false, "load fighters, events and fights in one transaction (replica sets only, chunked otherwise)")
+	finishWeight := fs.Float64("finish-weight", 0, "weight KO/TKO and submission results this much more in the ratings (0 ignores the method)")
+
+	fs.Parse(args)
+
+	client, err := utils.CreateProxyClient()
+	if err != nil {
+		log.Fatalf("[Proxy Client Build Failed: %v]", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mc, err := utils.ConnectMongo(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mc.Disconnect(context.Background())
+
+	// wrap a collection mode so every run starts from empty maps and ends with the batch load. ctx is
+	// cancelled when the lock's lease is lost, the requests and the load stop with it
+	job := func(name string, every time.Duration, collect func(context.Context, *http.Client) error) scheduler.Job {
+		return scheduler.Job{Name: name, Every: every, Run: func(ctx context.Context) error {
+			utils.ResetMaps()
+			if err := collect(ctx, client); err != nil {
+				return err
+			}
+			summary, err := utils.RunBatches(ctx, utils.BatchOptions{Transaction: *tx})
+			if rerr := utils.ReportSummary(summary, ""); rerr != nil {
+				log.Printf("failed to report load summary: %v", rerr)
+			}
+
+			afterLoad(ctx, mc.Database("ufc"), summary, loadOptions{
+				Ratings: ratingsOptions(*finishWeight, false),
+			})
+			return err
+		}}
+	}
+
+	s := scheduler.New(
+		[]scheduler.Job{
+			job("update", *updateEvery, collectUpdate),
+			job("upcoming", *upcomingEvery, collectUpcoming),
+			job("full", *fullEvery, collectAll),
+		},
+		scheduler.NewLock(mc.Database("ufc"), scrapeLockName, scrapeLockTTL),
+		*jitter,
+		scheduler.Backoff{Base: *backoffBase, Max: *backoffMax},
+	)
+
+	srv := &http.Server{Addr: *addr, Handler: s.StatusHandler()}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("status server failed: %v", err)
+		}
+	}()
+
+	fmt.Printf("[Scheduler Started | Status on http://%s/status]\n\n", *addr)
+
+	s.Start(ctx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+
+	fmt.Println("\n[Scheduler Stopped]")
+}
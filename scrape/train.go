@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/anthonybliss1/ufc-api/scrape/predict"
+	"github.com/anthonybliss1/ufc-api/scrape/utils"
+)
+
+// 'scrape train' fits the win probability model on the stored fights and writes it to a model file
+// the api loads (MODEL_PATH). it only reads the database and runs entirely on the cpu
+func runTrain(args []string) {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+
+	out := fs.String("out", "model.json", "path of the model file to write")
+	epochs := fs.Int("epochs", predict.DefaultTrainOptions().Epochs, "gradient descent passes over the training rows")
+	l2 := fs.Float64("l2", predict.DefaultTrainOptions().L2, "weight penalty")
+
+	fs.Parse(args)
+
+	ctx := context.Background()
+
+	mc, err := utils.ConnectMongo(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mc.Disconnect(ctx)
+
+	opts := predict.DefaultTrainOptions()
+	opts.Epochs = *epochs
+	opts.L2 = *l2
+
+	fmt.Println("[Training Win Probability Model...]")
+
+	model, err := predict.Train(ctx, mc.Database("ufc"), opts)
+	if err != nil {
+		log.Fatalf("[Training Failed: %v]", err)
+	}
+
+	if err := model.Save(*out); err != nil {
+		log.Fatalf("[Failed to write model: %v]", err)
+	}
+
+	fmt.Printf("✅ [Model written to %s | %d rows | log loss %.4f | %s ratings]\n", *out, model.Samples, model.LogLoss, model.RatingModel)
+	for i, f := range model.Features {
+		fmt.Printf("  %-8s %+.4f\n", f, model.Weights[i])
+	}
+}
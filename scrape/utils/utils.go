@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -11,6 +12,7 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -96,13 +98,13 @@ func CreateProxyClient() (*http.Client, error) {
 	return client, nil
 }
 
-func IterateFighters(client *http.Client) error {
+func IterateFighters(ctx context.Context, client *http.Client) error {
 	for _, letter := range Letters {
 		fmt.Printf("[Scraping fighters under letter '%s']\n", letter)
 		page := fmt.Sprintf("http://ufcstats.com/statistics/fighters?char=%s&page=all", letter)
 
 		// build request
-		req, err := http.NewRequest("GET", page, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", page, nil)
 		if err != nil {
 			return fmt.Errorf("failed to construct request alphabetical page: %s | %v", letter, err)
 		}
@@ -131,10 +133,13 @@ func IterateFighters(client *http.Client) error {
 		// first find the table rows
 		rows := doc.Find(".b-statistics__table tbody tr")
 
-		// iterate through each row
-		rows.Each(func(i int, tr *goquery.Selection) {
+		// iterate through each row, a failed fighter is skipped but a cancelled run stops
+		rows.EachWithBreak(func(i int, tr *goquery.Selection) bool {
+			if ctx.Err() != nil {
+				return false
+			}
 			if i == 0 {
-				return
+				return true
 			}
 
 			td := tr.ChildrenFiltered("td") // td represents each cell (or column) in the row
@@ -145,7 +150,8 @@ func IterateFighters(client *http.Client) error {
 			link, _ := td.Eq(0).Find("a").Attr("href")
 			u, err := url.Parse(link)
 			if err != nil {
-				log.Panic("cannot parse url")
+				fmt.Printf("cannot parse fighter url %q: %v\n", link, err)
+				return true
 			}
 			fighterID := path.Base(u.Path)
 
@@ -156,23 +162,27 @@ func IterateFighters(client *http.Client) error {
 			fmt.Printf("Fighter Name: %s | Fighter Link: %s | FighterID: %s\n", fighterName, link, fighterID)
 
 			// navigate to the profile page and collect all data on the fighter
-			err = CollectFighterData(&fighter, link, client)
+			err = CollectFighterData(ctx, &fighter, link, client)
 			if err != nil {
 				fmt.Printf("failed to collect data from fighter profile page: %v", err)
-				return
+				return true
 			}
 
 			// store the collected struct in a FighterMap type variable
 			fighterMap[fighter.ID] = &fighter
+			return true
 		})
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
 	}
 
 	return nil
 }
 
-func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client *http.Client) error {
+func CollectFighterData(ctx context.Context, fighter *data.Fighter, fighterProfileLink string, client *http.Client) error {
 	// build request
-	req, err := http.NewRequest("GET", fighterProfileLink, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fighterProfileLink, nil)
 	if err != nil {
 		return fmt.Errorf("failed to construct fighter profile request: %v", err)
 	}
@@ -203,13 +213,13 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 	fighterStats := page.Find(".b-fight-details").First() // contains physical stats, career stats, and fights
 	// quick check to make sure we found something
 	if fighterStats.Length() == 0 {
-		log.Fatal("No <fight-details> found")
+		return errors.New("No <fight-details> found")
 	}
 
 	pStats := fighterStats.Find("div .b-list__box-list").First() // contains physical stats
 	// quick check to make sure we found something
 	if pStats.Length() == 0 {
-		log.Fatal("No <ul> found")
+		return errors.New("No <ul> found")
 	}
 
 	currentRecord := strings.TrimSpace(page.Find(".b-content__title-record").Text())
@@ -222,6 +232,9 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 	// PHYSCIAL AND CAREER STATISTICS COLLECTION
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 
+	// the first parse error inside the goquery callbacks, returned once the loop is done
+	var scrapeErr error
+
 	pStats.Each(func(i int, ul *goquery.Selection) {
 		li := ul.ChildrenFiltered("li")
 
@@ -243,7 +256,8 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 		} else {
 			parsedDOB, err := time.Parse("Jan 2, 2006", dob)
 			if err != nil {
-				log.Fatalf("failed to parse D.O.B: %v", err)
+				scrapeErr = fmt.Errorf("failed to parse D.O.B: %v", err)
+				return
 			}
 			fighter.DOB = &parsedDOB
 		}
@@ -254,6 +268,9 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 			fmt.Println("DOB: nil")
 		}
 	})
+	if scrapeErr != nil {
+		return scrapeErr
+	}
 
 	// LEFT SIDE OF CAREER STATS
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -266,7 +283,8 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 
 		slpm, err := strconv.ParseFloat(strings.TrimSpace(li.Eq(0).Clone().Find("i").Remove().End().Text()), 32)
 		if err != nil {
-			log.Fatalf("failed to format float SLpM: %v", err)
+			scrapeErr = fmt.Errorf("failed to format float SLpM: %v", err)
+			return
 		}
 
 		fighter.CareerStats.SLpM = float32(slpm)
@@ -277,7 +295,8 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 
 		sapm, err := strconv.ParseFloat(strings.TrimSpace(li.Eq(2).Clone().Find("i").Remove().End().Text()), 32)
 		if err != nil {
-			log.Fatalf("failed to format float SApM: %v", err)
+			scrapeErr = fmt.Errorf("failed to format float SApM: %v", err)
+			return
 		}
 
 		fighter.CareerStats.SApM = float32(sapm)
@@ -286,6 +305,9 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 		fighter.CareerStats.StrDef = strings.TrimSpace(li.Eq(3).Clone().Find("i").Remove().End().Text())
 		fmt.Printf("Str. Def.: %s\n", fighter.CareerStats.StrDef)
 	})
+	if scrapeErr != nil {
+		return scrapeErr
+	}
 
 	// RIGHT SIDE OF CAREER STATS
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -298,7 +320,8 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 
 		tdAvg, err := strconv.ParseFloat(strings.TrimSpace(li.Eq(1).Clone().Find("i").Remove().End().Text()), 32)
 		if err != nil {
-			log.Fatalf("failed to format float TdAvg: %v", err)
+			scrapeErr = fmt.Errorf("failed to format float TdAvg: %v", err)
+			return
 		}
 
 		fighter.CareerStats.TdAvg = float32(tdAvg)
@@ -312,19 +335,23 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 
 		subAvg, err := strconv.ParseFloat(strings.TrimSpace(li.Eq(4).Clone().Find("i").Remove().End().Text()), 32)
 		if err != nil {
-			log.Fatalf("failed to format float SubAvg: %v", err)
+			scrapeErr = fmt.Errorf("failed to format float SubAvg: %v", err)
+			return
 		}
 
 		fighter.CareerStats.SubAvg = float32(subAvg)
 		fmt.Printf("Sub. Avg.: %.2f\n", fighter.CareerStats.SubAvg)
 	})
+	if scrapeErr != nil {
+		return scrapeErr
+	}
 
 	// FIGHT HISTORY
 	// ~~~~~~~~~~~~~~
 
 	fightRows := fighterStats.Find(".b-fight-details__table tbody tr")
 	if fightRows.Length() == 0 {
-		log.Fatal("cannot find fightRows")
+		return errors.New("cannot find fightRows")
 	}
 
 	// for debugging outputs
@@ -340,21 +367,23 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 	fmt.Printf("| Total Fights Found: %d |\n", numFights)
 	fmt.Print(" -----------------------\n\n")
 
-	fightRows.Each(func(i int, tr *goquery.Selection) {
+	fightRows.EachWithBreak(func(i int, tr *goquery.Selection) bool {
 		if i == 0 {
-			return
+			return true
 		}
 
 		// first need to capture the fight url for each of the fighter's fights
 		td := tr.ChildrenFiltered("td")
 		fightLink, e := td.Eq(0).Find("a").Attr("href")
 		if !e {
-			log.Fatal("cannot find fight link")
+			scrapeErr = errors.New("cannot find fight link")
+			return false
 		}
 		// parse out link so i can grab the base path so i can save it as the FightID
 		fLink, err := url.Parse(fightLink)
 		if err != nil {
-			log.Fatalf("failed to parse fight url: %v", err)
+			scrapeErr = fmt.Errorf("failed to parse fight url: %v", err)
+			return false
 		}
 		fightID := path.Base(fLink.Path)
 
@@ -364,19 +393,21 @@ func CollectFighterData(fighter *data.Fighter, fighterProfileLink string, client
 		// for each fight in the fighters profile, find the fight link, capture the FightID and stats -> create fights struct
 		fmt.Printf("Fight #%d | Fight Link: %s | FightID: %s\n\n", i, fightLink, fightID)
 
-		if err = CollectFightData(&fight, fightLink, fighterProfileLink, client); err != nil {
-			log.Fatalf("failed to collect fight data: %v", err)
+		if err = CollectFightData(ctx, &fight, fightLink, fighterProfileLink, client); err != nil {
+			scrapeErr = fmt.Errorf("failed to collect fight data: %v", err)
+			return false
 		}
 
 		// store the collected struct in a FightMap type variable
 		fightMap[fight.ID] = &fight
+		return true
 	})
 
-	return nil
+	return scrapeErr
 }
 
-func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, client *http.Client) error {
-	requestFight, err := http.NewRequest("GET", fightLink, nil)
+func CollectFightData(ctx context.Context, fight *data.Fight, fightLink string, reqReferer string, client *http.Client) error {
+	requestFight, err := http.NewRequestWithContext(ctx, "GET", fightLink, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request for fight: %v", err)
 	}
@@ -420,15 +451,18 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 	eventLink, _ := fightEvent.Attr("href")
 
-	if err := CollectEventDetails(&event, eventLink, fightLink, client); err != nil {
-		log.Fatalf("failed to collect fighter event: %v", err)
+	if err := CollectEventDetails(ctx, &event, eventLink, fightLink, client); err != nil {
+		return fmt.Errorf("failed to collect fighter event: %v", err)
 	}
 
 	// store the collected struct in an EventMap type variable
 	eventMap[event.ID] = &event
 
-	// add EventID to Fight struct
+	// add EventID and the event date to Fight struct
 	fight.EventID = event.ID
+	date := event.Date
+	fight.Date = &date
+	fight.Bout = slices.Index(event.Card, fight.ID) + 1
 
 	participants := fightDetails.Find(".b-fight-details__person")
 	p1Header := participants.Eq(0)
@@ -439,7 +473,7 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 	p1Link, _ := p1Header.Find("a").Attr("href")
 	p1Url, err := url.Parse(p1Link)
 	if err != nil {
-		log.Fatalf("failed to parse participant ID: %v", err)
+		return fmt.Errorf("failed to parse participant ID: %v", err)
 	}
 	p1ID := path.Base(p1Url.Path)
 
@@ -447,7 +481,7 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 	p2Link, _ := p2Header.Find("a").Attr("href")
 	p2Url, err := url.Parse(p2Link)
 	if err != nil {
-		log.Fatalf("failed to parse participant ID: %v", err)
+		return fmt.Errorf("failed to parse participant ID: %v", err)
 	}
 	p2ID := path.Base(p2Url.Path)
 
@@ -462,6 +496,7 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 	fmt.Printf("P1: %s | %s - %s \nP2: %s | %s - %s\n", p1.FighterName, p1.FighterID, p1.Outcome, p2.FighterName, p2.FighterID, p2.Outcome)
 
 	fight.FightDetail = strings.TrimSpace(fightDetails.Find(".b-fight-details__fight-head").First().Text())
+	fight.Division = data.DivisionOf(fight.FightDetail)
 	fmt.Printf("Type: %s\n", fight.FightDetail)
 
 	fightDetailsRow1 := fightDetails.Find(".b-fight-details__text").Eq(0)
@@ -473,7 +508,7 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 	roundFrmt := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(round), "Round:"))
 	fight.Round, err = strconv.Atoi(roundFrmt)
 	if err != nil {
-		log.Fatalf("failed to parse int Round: %v", err)
+		return fmt.Errorf("failed to parse int Round: %v", err)
 	}
 	fmt.Printf("Round: %d\n", fight.Round)
 
@@ -508,6 +543,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 	re := regexp.MustCompile(`\s+`)
 	details = re.ReplaceAllString(details, " ")
 	fight.MethodDetail = details
+	fight.MethodCategory = data.MethodCategoryOf(fight.Method)
+	fight.Technique = data.TechniqueOf(fight.Method, fight.MethodDetail)
 
 	fmt.Printf("Details: %s\n\n", fight.MethodDetail)
 
@@ -522,9 +559,12 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 	// totalsTable will always be the first table on the page if present
 	totalsTable := totalsSection.Find("table[style] tbody tr")
 	if totalsSection.Length() > 0 && totalsTable.Length() == 0 {
-		log.Fatal("failed to find totalsTable when totalsSection is found")
+		return errors.New("failed to find totalsTable when totalsSection is found")
 	}
 
+	// the first parse error inside the table callbacks, returned once both tables are read
+	var scrapeErr error
+
 	totalsTable.Each(func(i int, tr *goquery.Selection) {
 		// there is only one row 'tr' so need to loop throught the 'td' children or columns
 		td := tr.ChildrenFiltered("td")
@@ -553,7 +593,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 2:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Sig. Str. : %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Sig. Str. : %v", err)
+					return
 				}
 				p1.SigStrL = p1l
 				p1.SigStrA = p1a
@@ -562,7 +603,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Sig. Str. : %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Sig. Str. : %v", err)
+					return
 				}
 				p2.SigStrL = p2l
 				p2.SigStrA = p2a
@@ -579,7 +621,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 4:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Total Str.: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Total Str.: %v", err)
+					return
 				}
 				p1.TotalStrL = p1l
 				p1.TotalStrA = p1a
@@ -588,7 +631,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Total Str.: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Total Str.: %v", err)
+					return
 				}
 				p2.TotalStrL = p2l
 				p2.TotalStrA = p2a
@@ -598,7 +642,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 5:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int TD: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int TD: %v", err)
+					return
 				}
 				p1.TdL = p1l
 				p1.TdA = p1a
@@ -607,7 +652,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int TD: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int TD: %v", err)
+					return
 				}
 				p2.TdL = p2l
 				p2.TdA = p2a
@@ -624,26 +670,30 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 7:
 				p1.Sub, err = strconv.Atoi(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Sub. Att.: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Sub. Att.: %v", err)
+					return
 				}
 				fmt.Printf("P1 Sub. Att.: %d\n", p1.Sub)
 
 				p2.Sub, err = strconv.Atoi(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Sub. Att.: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Sub. Att.: %v", err)
+					return
 				}
 				fmt.Printf("P2 Sub. Att.: %d\n\n", p2.Sub)
 
 			case 8:
 				p1.Rev, err = strconv.Atoi(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Rev.: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Rev.: %v", err)
+					return
 				}
 				fmt.Printf("P1 Rev.: %d\n", p1.Rev)
 
 				p2.Rev, err = strconv.Atoi(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Rev.: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Rev.: %v", err)
+					return
 				}
 				fmt.Printf("P2 Rev.: %d\n\n", p2.Rev)
 
@@ -688,7 +738,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Head: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Head: %v", err)
+					return
 				}
 				p1.HeadL = p1l
 				p1.HeadA = p1a
@@ -697,7 +748,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Head: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Head: %v", err)
+					return
 				}
 				p2.HeadL = p2l
 				p2.HeadA = p2a
@@ -707,7 +759,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 4:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Body: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Body: %v", err)
+					return
 				}
 				p1.BodyL = p1l
 				p1.BodyA = p1a
@@ -716,7 +769,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Body: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Body: %v", err)
+					return
 				}
 				p2.BodyL = p2l
 				p2.BodyA = p2a
@@ -726,7 +780,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 5:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Leg: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Leg: %v", err)
+					return
 				}
 				p1.LegL = p1l
 				p1.LegA = p1a
@@ -735,7 +790,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Leg: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Leg: %v", err)
+					return
 				}
 				p2.LegL = p2l
 				p2.LegA = p2a
@@ -745,7 +801,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 6:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Distance: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Distance: %v", err)
+					return
 				}
 				p1.DistanceL = p1l
 				p1.DistanceA = p1a
@@ -754,7 +811,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Distance: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Distance: %v", err)
+					return
 				}
 				p2.DistanceL = p2l
 				p2.DistanceA = p2a
@@ -764,7 +822,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 7:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Clinch: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Clinch: %v", err)
+					return
 				}
 				p1.ClinchL = p1l
 				p1.ClinchA = p1a
@@ -773,7 +832,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Clinch: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Clinch: %v", err)
+					return
 				}
 				p2.ClinchL = p2l
 				p2.ClinchA = p2a
@@ -783,7 +843,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 			case 8:
 				p1l, p1a, err := extracNums(p1Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Ground: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Ground: %v", err)
+					return
 				}
 				p1.GroundL = p1l
 				p1.GroundA = p1a
@@ -792,7 +853,8 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 
 				p2l, p2a, err := extracNums(p2Text)
 				if err != nil {
-					log.Fatalf("failed to parse int Ground: %v", err)
+					scrapeErr = fmt.Errorf("failed to parse int Ground: %v", err)
+					return
 				}
 				p2.GroundL = p2l
 				p2.GroundA = p2a
@@ -802,6 +864,10 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 		})
 	})
 
+	if scrapeErr != nil {
+		return scrapeErr
+	}
+
 	fight.Participants = append(fight.Participants, p1)
 	fight.Participants = append(fight.Participants, p2)
 
@@ -811,14 +877,14 @@ func CollectFightData(fight *data.Fight, fightLink string, reqReferer string, cl
 // COMPLETED EVENT DETAILS
 // ~~~~~~~~~~~~~~~~~~~~~
 
-func CollectEventDetails(event *data.Event, eventLink string, reqReferer string, client *http.Client) error {
+func CollectEventDetails(ctx context.Context, event *data.Event, eventLink string, reqReferer string, client *http.Client) error {
 	l, err := url.Parse(eventLink)
 	if err != nil {
-		log.Fatalf("failed to parse fight url: %v", err)
+		return fmt.Errorf("failed to parse fight url: %v", err)
 	}
 	event.ID = path.Base(l.Path)
 
-	request, err := http.NewRequest("GET", eventLink, nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", eventLink, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request for event: %v", err)
 	}
@@ -853,11 +919,22 @@ func CollectEventDetails(event *data.Event, eventLink string, reqReferer string,
 
 	event.Date, err = time.Parse("January 2, 2006", strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(listItems.Eq(0).Text()), "Date:")))
 	if err != nil {
-		log.Fatalf("failed to parse event date: %v", err)
+		return fmt.Errorf("failed to parse event date: %v", err)
 	}
 
 	event.Location = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(listItems.Eq(1).Text()), "Location:"))
 
+	// the fight rows are in card order, main event first. each row links to its fight
+	page.Find(".b-fight-details__table tbody tr").Each(func(i int, tr *goquery.Selection) {
+		link, ok := tr.Attr("data-link")
+		if !ok {
+			return
+		}
+		if u, err := url.Parse(link); err == nil {
+			event.Card = append(event.Card, path.Base(u.Path))
+		}
+	})
+
 	fmt.Println("[ Event Details ]")
 	fmt.Printf("Event Name: %s | Event Link: %s | EventID: %s\n", event.Name, eventLink, event.ID)
 	fmt.Printf("Date: %s\n", event.Date.Format("January 2, 2006"))
@@ -869,10 +946,10 @@ func CollectEventDetails(event *data.Event, eventLink string, reqReferer string,
 // UPCOMING EVENT DATA
 // ~~~~~~~~~~~~~~~~~~~~~
 
-func IterateUpcomingEvents(event *data.Event, client *http.Client) error {
+func IterateUpcomingEvents(ctx context.Context, event *data.Event, client *http.Client) error {
 	eventUpcomingLink := "http://ufcstats.com/statistics/events/upcoming?page=all"
 
-	requestEvent, err := http.NewRequest("GET", eventUpcomingLink, nil)
+	requestEvent, err := http.NewRequestWithContext(ctx, "GET", eventUpcomingLink, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request for event: %v", err)
 	}
@@ -902,14 +979,15 @@ func IterateUpcomingEvents(event *data.Event, client *http.Client) error {
 	// find table containing upcoming fights
 	events := page.Find("table.b-statistics__table-events tbody tr")
 	if page.Length() == 0 {
-		log.Fatal("failed to find completed events table")
+		return errors.New("failed to find completed events table")
 	}
 
-	// looping through every upcoming fight in the table
-	events.Each(func(i int, tr *goquery.Selection) {
+	// looping through every upcoming fight in the table, stopping at the first error
+	var scrapeErr error
+	events.EachWithBreak(func(i int, tr *goquery.Selection) bool {
 		//skip first column (is an empty row)
 		if i == 0 {
-			return
+			return true
 		}
 
 		// each td child is a column, first column (Eq(0)) will contain the link the event data
@@ -920,7 +998,8 @@ func IterateUpcomingEvents(event *data.Event, client *http.Client) error {
 
 		eventURL, err := url.Parse(upcomingEventLink)
 		if err != nil {
-			log.Fatalf("failed to parse upcoming event url: %v", err)
+			scrapeErr = fmt.Errorf("failed to parse upcoming event url: %v", err)
+			return false
 		}
 		eventID := path.Base(eventURL.Path)
 
@@ -930,20 +1009,22 @@ func IterateUpcomingEvents(event *data.Event, client *http.Client) error {
 		upcomingEvent := data.UpcomingEvent{ID: eventID}
 
 		// then navigate to the event page which contains all fights, iterate the fights
-		if err := CollectUpcomingEventData(&upcomingEvent, upcomingEventLink, eventUpcomingLink, client); err != nil {
-			log.Fatalf("error on fights page of upcoming event: %v", err)
+		if err := CollectUpcomingEventData(ctx, &upcomingEvent, upcomingEventLink, eventUpcomingLink, client); err != nil {
+			scrapeErr = fmt.Errorf("error on fights page of upcoming event: %v", err)
+			return false
 		}
 
 		// add the upcoming event struct to the upcoming event map
 		upcomingEventMap[upcomingEvent.ID] = &upcomingEvent
+		return true
 	})
 
-	return nil
+	return scrapeErr
 }
 
 // navigate to the upcoming event page and iterate the list of fights
-func CollectUpcomingEventData(upcomingEvent *data.UpcomingEvent, eventLink string, referer string, client *http.Client) error {
-	request, err := http.NewRequest("GET", eventLink, nil)
+func CollectUpcomingEventData(ctx context.Context, upcomingEvent *data.UpcomingEvent, eventLink string, referer string, client *http.Client) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", eventLink, nil)
 	if err != nil {
 		return fmt.Errorf("failed to build request to fights page of upcoming event: %v", err)
 	}
@@ -978,7 +1059,7 @@ func CollectUpcomingEventData(upcomingEvent *data.UpcomingEvent, eventLink strin
 
 	upcomingEvent.Date, err = time.Parse("January 2, 2006", strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(listItems.Eq(0).Text()), "Date:")))
 	if err != nil {
-		log.Fatalf("failed to parse upcomingEvent date: %v", err)
+		return fmt.Errorf("failed to parse upcomingEvent date: %v", err)
 	}
 
 	upcomingEvent.Location = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(listItems.Eq(1).Text()), "Location:"))
@@ -991,11 +1072,12 @@ func CollectUpcomingEventData(upcomingEvent *data.UpcomingEvent, eventLink strin
 	// find the table that contains all the fights for the upcoming event
 	fights := page.Find(".b-fight-details__table tbody tr")
 	if fights.Length() == 0 {
-		log.Fatal("failed to find fights for upcoming events")
+		return errors.New("failed to find fights for upcoming events")
 	}
 
-	// loop through each fight in the table (rows)
-	fights.Each(func(i int, tr *goquery.Selection) {
+	// loop through each fight in the table (rows), stopping at the first error
+	var scrapeErr error
+	fights.EachWithBreak(func(i int, tr *goquery.Selection) bool {
 		// each child will be a column in the specific row, column 5 (Eq(4)) will contain the link to the matchup
 		td := tr.ChildrenFiltered("td")
 
@@ -1005,22 +1087,26 @@ func CollectUpcomingEventData(upcomingEvent *data.UpcomingEvent, eventLink strin
 		p1Name := strings.TrimSpace(participants.Eq(0).Text())
 		p1Link, e := participants.Eq(0).Find("a").Attr("href")
 		if !e {
-			log.Fatal("cannot find p1 link for ID")
+			scrapeErr = errors.New("cannot find p1 link for ID")
+			return false
 		}
 		u1, err := url.Parse(p1Link)
 		if err != nil {
-			log.Fatalf("failed to parse p1Link for ID: %v", err)
+			scrapeErr = fmt.Errorf("failed to parse p1Link for ID: %v", err)
+			return false
 		}
 		p1ID := path.Base(u1.Path)
 
 		p2Name := strings.TrimSpace(participants.Eq(1).Text())
 		p2Link, e := participants.Eq(1).Find("a").Attr("href")
 		if !e {
-			log.Fatal("cannot find p2 link for ID")
+			scrapeErr = errors.New("cannot find p2 link for ID")
+			return false
 		}
 		u2, err := url.Parse(p2Link)
 		if err != nil {
-			log.Fatalf("failed to parse p2Link for ID: %v", err)
+			scrapeErr = fmt.Errorf("failed to parse p2Link for ID: %v", err)
+			return false
 		}
 		p2ID := path.Base(u2.Path)
 
@@ -1031,11 +1117,13 @@ func CollectUpcomingEventData(upcomingEvent *data.UpcomingEvent, eventLink strin
 		// parse matchup link for fight ID
 		upcomingFightLink, e := td.Eq(4).Find("a").Attr("data-link")
 		if !e {
-			log.Fatal("cannot find upcomingFightLink for ID")
+			scrapeErr = errors.New("cannot find upcomingFightLink for ID")
+			return false
 		}
 		ufl, err := url.Parse(upcomingFightLink)
 		if err != nil {
-			log.Fatalf("failed to parse upcoming fight link for ID: %v", err)
+			scrapeErr = fmt.Errorf("failed to parse upcoming fight link for ID: %v", err)
+			return false
 		}
 		upcomingFightID := path.Base(ufl.Path)
 
@@ -1051,36 +1139,26 @@ func CollectUpcomingEventData(upcomingEvent *data.UpcomingEvent, eventLink strin
 		fmt.Printf("FightID: %s\nP1: %s | %s\nP2: %s | %s\n\n",
 			upcomingFight.ID, upcomingFight.Participants[0].Name, upcomingFight.Participants[0].ID,
 			upcomingFight.Participants[1].Name, upcomingFight.Participants[1].ID)
+		return true
 	})
 
-	return nil
+	return scrapeErr
 }
 
 // ADDING NEW DATA
 // ~~~~~~~~~~~~~~~~~~~~~
 
-func RunUpdate(webClient *http.Client) error {
+func RunUpdate(ctx context.Context, webClient *http.Client) error {
 	const eventPage = "http://ufcstats.com/statistics/events/completed?page=all"
 
 	var newEvents = make([]string, 0, 10)
 
-	connString := os.Getenv("MONGO_URI")
-	if connString == "" {
-		log.Fatal("mongodb connection string empty")
-	}
-
-	ctx := context.Background()
-
-	client, err := mongo.Connect(options.Client().ApplyURI(connString))
+	client, err := ConnectMongo(ctx)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	if err := client.Ping(ctx, nil); err != nil {
-		log.Fatalf("could not connect to MongoDB: %v", err)
+		return err
 	}
 	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
+		if err := client.Disconnect(context.WithoutCancel(ctx)); err != nil {
 			log.Printf("disconnect error: %v", err)
 		}
 	}()
@@ -1099,7 +1177,7 @@ func RunUpdate(webClient *http.Client) error {
 
 	recentEventID := ev.ID
 
-	request, err := http.NewRequest("GET", eventPage, nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", eventPage, nil)
 	if err != nil {
 		return fmt.Errorf("failed to build request to events page: %v", err)
 	}
@@ -1127,9 +1205,12 @@ func RunUpdate(webClient *http.Client) error {
 
 	allEvents := page.Find("table.b-statistics__table-events tbody tr")
 	if page.Length() == 0 {
-		log.Fatal("failed to find completed events table")
+		return errors.New("failed to find completed events table")
 	}
 
+	// the first error inside the goquery callbacks, they stop at it
+	var scrapeErr error
+
 	allEvents.EachWithBreak(func(i int, tr *goquery.Selection) bool {
 		//skip first column (is an empty row)
 		if i <= 1 {
@@ -1142,7 +1223,8 @@ func RunUpdate(webClient *http.Client) error {
 
 		u, err := url.Parse(link)
 		if err != nil {
-			log.Panic("cannot parse url")
+			scrapeErr = fmt.Errorf("cannot parse url: %v", err)
+			return false
 		}
 		eventID := path.Base(u.Path)
 
@@ -1153,11 +1235,14 @@ func RunUpdate(webClient *http.Client) error {
 		newEvents = append(newEvents, link)
 		return true
 	})
+	if scrapeErr != nil {
+		return scrapeErr
+	}
 
 	if len(newEvents) > 0 {
 		fmt.Print("\n[Collecting New Data...]\n\n")
 		for _, link := range newEvents {
-			request, err := http.NewRequest("GET", link, nil)
+			request, err := http.NewRequestWithContext(ctx, "GET", link, nil)
 			if err != nil {
 				return fmt.Errorf("failed to create newEvent request: %v", err)
 			}
@@ -1186,10 +1271,10 @@ func RunUpdate(webClient *http.Client) error {
 			fightRows := page.Find(".b-fight-details__table tbody tr")
 
 			if fightRows.Length() == 0 {
-				log.Fatal("Cannot find fight rows")
+				return errors.New("cannot find fight rows")
 			}
 
-			fightRows.Each(func(i int, tr *goquery.Selection) {
+			fightRows.EachWithBreak(func(i int, tr *goquery.Selection) bool {
 				td := tr.ChildrenFiltered("td")
 
 				// grab the names of each fighter in the fight and will need to update their records
@@ -1202,13 +1287,15 @@ func RunUpdate(webClient *http.Client) error {
 
 				u1, err := url.Parse(p1Link)
 				if err != nil {
-					log.Panic("cannot parse url")
+					scrapeErr = fmt.Errorf("cannot parse url: %v", err)
+					return false
 				}
 				p1ID := path.Base(u1.Path)
 
 				u2, err := url.Parse(p2Link)
 				if err != nil {
-					log.Panic("cannot parse url")
+					scrapeErr = fmt.Errorf("cannot parse url: %v", err)
+					return false
 				}
 				p2ID := path.Base(u2.Path)
 
@@ -1217,19 +1304,25 @@ func RunUpdate(webClient *http.Client) error {
 
 				fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
 				fmt.Printf("Fighter Name: %s | Fighter Link: %s | FighterID: %s\n", f1.Name, p1Link, f1.ID)
-				if err := CollectFighterData(&f1, p1Link, webClient); err != nil {
-					log.Fatalf("failed to collect p1 data: %v", err)
+				if err := CollectFighterData(ctx, &f1, p1Link, webClient); err != nil {
+					scrapeErr = fmt.Errorf("failed to collect p1 data: %v", err)
+					return false
 				}
 
 				fmt.Print("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
 				fmt.Printf("Fighter Name: %s | Fighter Link: %s | FighterID: %s\n", f2.Name, p2Link, f2.ID)
-				if err := CollectFighterData(&f2, p2Link, webClient); err != nil {
-					log.Fatalf("failed to collect p2 data: %v", err)
+				if err := CollectFighterData(ctx, &f2, p2Link, webClient); err != nil {
+					scrapeErr = fmt.Errorf("failed to collect p2 data: %v", err)
+					return false
 				}
 
 				fighterMap[f1.ID] = &f1
 				fighterMap[f2.ID] = &f2
+				return true
 			})
+			if scrapeErr != nil {
+				return scrapeErr
+			}
 		}
 	} else {
 		fmt.Print("\n[No New Events Found]\n\n")
@@ -1279,51 +1372,225 @@ func extracNums(s string) (i1, i2 int, err error) {
 // BATCHING / POPULATING DATA IN DB
 // ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 
-func RunBatches() {
+// connect to the mongodb deployment in MONGO_URI and make sure it is reachable
+func ConnectMongo(ctx context.Context) (*mongo.Client, error) {
 	connString := os.Getenv("MONGO_URI")
 	if connString == "" {
-		log.Fatal("mongodb connection string empty")
+		return nil, errors.New("mongodb connection string empty")
 	}
 
-	ctx := context.Background()
-
 	client, err := mongo.Connect(options.Client().ApplyURI(connString))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
-		log.Fatalf("could not connect to MongoDB: %v", err)
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("could not connect to MongoDB: %v", err)
+	}
+
+	return client, nil
+}
+
+// clear everything collected so far so a long running process (scrape serve) starts each run fresh
+func ResetMaps() {
+	clear(fighterMap)
+	clear(fightMap)
+	clear(eventMap)
+	clear(upcomingEventMap)
+	clear(upcomingFightMap)
+}
+
+// options for loading a scrape run into mongo
+type BatchOptions struct {
+	Transaction bool // load fighters, events and fights all-or-nothing when the deployment supports transactions
+}
+
+func RunBatches(ctx context.Context, opts BatchOptions) (*data.RunSummary, error) {
+	summary := data.NewRunSummary()
+
+	client, err := ConnectMongo(ctx)
+	if err != nil {
+		return finishSummary(summary, err)
 	}
 	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
+		if err := client.Disconnect(context.WithoutCancel(ctx)); err != nil {
 			log.Printf("disconnect error: %v", err)
 		}
 	}()
 
 	db := client.Database("ufc")
 
-	if err := data.BatchLoad(ctx, db.Collection("fighters"), fighterMap, 1000); err != nil {
-		log.Fatalf("fighters load failed: %v", err)
+	if opts.Transaction {
+		if data.SupportsTransactions(ctx, client) {
+			summary.Mode = data.ModeTransaction
+		} else {
+			fmt.Print("[Transactions need a replica set, falling back to chunked loads...]\n\n")
+		}
+	}
+
+	err = loadCompleted(ctx, client, db, summary)
+	if err == nil {
+		err = loadUpcoming(ctx, db, summary)
+	}
+	finishSummary(summary, err)
+
+	// keep the change log of every run, failed and cancelled ones included
+	if serr := data.StoreChangeLog(context.WithoutCancel(ctx), db, summary); serr != nil {
+		log.Printf("%v", serr)
+	}
+
+	return summary, err
+}
+
+// load fighters, events and fights. in transaction mode either all three commit or none of them do
+func loadCompleted(ctx context.Context, client *mongo.Client, db *mongo.Database, summary *data.RunSummary) error {
+	load := func(ctx context.Context) ([]*data.LoadSummary, error) {
+		loaded := make([]*data.LoadSummary, 0, 3)
+
+		fighterIDs := make([]string, 0, len(fighterMap))
+		for id := range fighterMap {
+			fighterIDs = append(fighterIDs, id)
+		}
+		if err := data.SeedFighterVersions(ctx, db, fighterIDs, summary.StartedAt); err != nil {
+			return loaded, err
+		}
+
+		s, err := data.BatchLoad(ctx, db.Collection("fighters"), fighterMap, 1000)
+		loaded = append(loaded, s)
+		if err != nil {
+			return loaded, fmt.Errorf("fighters load failed: %w", err)
+		}
+
+		// every fighter that was added or changed gets its new state archived in 'fighterVersions'
+		if err := data.ArchiveFighterVersions(ctx, db, fighterMap, s, summary.StartedAt); err != nil {
+			return loaded, err
+		}
+		s, err = data.BatchLoad(ctx, db.Collection("events"), eventMap, 1000)
+		loaded = append(loaded, s)
+		if err != nil {
+			return loaded, fmt.Errorf("events load failed: %w", err)
+		}
+		s, err = data.BatchLoad(ctx, db.Collection("fights"), fightMap, 1000)
+		loaded = append(loaded, s)
+		if err != nil {
+			return loaded, fmt.Errorf("fights load failed: %w", err)
+		}
+
+		return loaded, nil
 	}
-	if err := data.BatchLoad(ctx, db.Collection("events"), eventMap, 1000); err != nil {
-		log.Fatalf("events load failed: %v", err)
+
+	if summary.Mode != data.ModeTransaction {
+		loaded, err := load(ctx)
+		summary.Collections = append(summary.Collections, loaded...)
+		return err
 	}
-	if err := data.BatchLoad(ctx, db.Collection("fights"), fightMap, 1000); err != nil {
-		log.Fatalf("fights load failed: %v", err)
+
+	sess, err := client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %v", err)
+	}
+	defer sess.EndSession(ctx)
+
+	// WithTransaction retries the callback on transient errors, only the last attempt is reported
+	var loaded []*data.LoadSummary
+	_, err = sess.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		var err error
+		loaded, err = load(ctx)
+		return nil, err
+	})
+	summary.Collections = append(summary.Collections, loaded...)
+
+	if err != nil {
+		// the transaction was aborted so none of the counted writes happened, keep only the failures
+		summary.RolledBack = true
+		for _, s := range loaded {
+			s.Inserted, s.Replaced, s.Unchanged = 0, 0, 0
+			s.Added, s.Updated = nil, nil
+		}
+		return fmt.Errorf("transaction rolled back: %w", err)
 	}
-	if err := data.BatchLoad(ctx, db.Collection("upcomingEvents"), upcomingEventMap, 1000); err != nil {
-		log.Fatalf("upcomingEvents load failed: %v", err)
+
+	return nil
+}
+
+// upcoming collections are swapped in whole (renameCollection cannot run inside a transaction)
+func loadUpcoming(ctx context.Context, db *mongo.Database, summary *data.RunSummary) error {
+	s, err := data.SwapLoad(ctx, db, "upcomingEvents", upcomingEventMap, data.UpcomingEventIndexes, 1000)
+	summary.Collections = append(summary.Collections, s)
+	if err != nil {
+		return fmt.Errorf("upcomingEvents load failed: %w", err)
 	}
 
 	// update fighter record in upcoming fights with 'Fighter' data before loading upcomingfights
 	if err := EnrichUpcomingFightsFromDB(ctx, db, upcomingFightMap); err != nil {
-		log.Fatalf("failed enriching upcoming fights: %v", err)
+		return fmt.Errorf("failed enriching upcoming fights: %v", err)
+	}
+
+	s, err = data.SwapLoad(ctx, db, "upcomingFights", upcomingFightMap, data.UpcomingFightIndexes, 1000)
+	summary.Collections = append(summary.Collections, s)
+	if err != nil {
+		return fmt.Errorf("upcomingFights load failed: %w", err)
+	}
+
+	return nil
+}
+
+func finishSummary(summary *data.RunSummary, err error) (*data.RunSummary, error) {
+	summary.FinishedAt = time.Now().UTC()
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	return summary, err
+}
+
+// how many added ids / updated documents are printed per collection before the change log is truncated
+const changeLogPrintLimit = 50
+
+// print the change log of a load and optionally save the full json summary to a file for other tooling
+func ReportSummary(summary *data.RunSummary, path string) error {
+	fmt.Print("\n[Change Log]\n")
+	fmt.Printf("Mode: %s | Rolled Back: %t\n", summary.Mode, summary.RolledBack)
+
+	for _, c := range summary.Collections {
+		fmt.Printf("\n[%s] added: %d | updated: %d | unchanged: %d | failed: %d\n",
+			c.Collection, c.Inserted, c.Replaced, c.Unchanged, len(c.Failed))
+
+		for i, id := range c.Added {
+			if i == changeLogPrintLimit {
+				fmt.Printf("  ... and %d more added\n", len(c.Added)-i)
+				break
+			}
+			fmt.Printf("  + %s\n", id)
+		}
+		for i, u := range c.Updated {
+			if i == changeLogPrintLimit {
+				fmt.Printf("  ... and %d more updated\n", len(c.Updated)-i)
+				break
+			}
+			fmt.Printf("  ~ %s\n", u.ID)
+			for _, f := range u.Fields {
+				fmt.Printf("      %s: %v -> %v\n", f.Field, f.Old, f.New)
+			}
+		}
+		for _, f := range c.Failed {
+			fmt.Printf("  ! %s: %s\n", f.ID, f.Message)
+		}
+	}
+
+	if summary.Error != "" {
+		fmt.Printf("\nError: %s\n", summary.Error)
+	}
+
+	if path == "" {
+		return nil
 	}
 
-	if err := data.BatchLoad(ctx, db.Collection("upcomingFights"), upcomingFightMap, 1000); err != nil {
-		log.Fatalf("upcomingFights load failed: %v", err)
+	out, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
 	}
+	return os.WriteFile(path, out, 0o644)
 }
 
 // use this to add data to the tale_of_the_tape (Fighter data) to all []Fighter entries in UpcomingFight